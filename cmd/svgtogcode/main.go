@@ -6,14 +6,21 @@
 // An example use is:
 //   svgtocode -in drawing.svg -size 270,180 -paper 297,210 -center -penup 35 -out out.gcode -simplify 0.1
 // Vector arguments, like -size and -paper take a pair of comma-separated values (no spaces).
+// If -size is not given, the image is plotted at the physical size given in the svg file.
 // If the -out <file> ends in .svg, the output is in svg format rather than gcode format.
+// Lines inside -keepout rectangles are removed, for example to leave room for a title
+// or to stay clear of clips holding the paper.
+// -pens chooses a pen for each Inkscape layer or stroke colour, for example
+// -pens outline=1,#ff0000=2, and the plotter pauses for a pen change between them.
 // All distance measurements are in millimeters.
 package main
 
 import (
 	"flag"
 	"fmt"
+	"math"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -54,13 +61,93 @@ func (fs *flagSizeValue) Set(s string) error {
 	return nil
 }
 
+// flagListValue is a comma-separated list of strings.
+type flagListValue []string
+
+func (fl *flagListValue) String() string {
+	return strings.Join(*fl, ",")
+}
+
+func (fl *flagListValue) Set(s string) error {
+	*fl = strings.Split(s, ",")
+	return nil
+}
+
+// flagRectsValue is a list of rectangles, each given as
+// x0,y0,x1,y1. Each use of the flag adds another rectangle.
+type flagRectsValue []paths.Bounds
+
+func (fr *flagRectsValue) String() string {
+	var parts []string
+	for _, b := range *fr {
+		parts = append(parts, fmt.Sprintf("%.2f,%.2f,%.2f,%.2f", b.Min[0], b.Min[1], b.Max[0], b.Max[1]))
+	}
+	return strings.Join(parts, " ")
+}
+
+func (fr *flagRectsValue) Set(s string) error {
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return fmt.Errorf("can't parse %q as a rectangle x0,y0,x1,y1", s)
+	}
+	var xs [4]float64
+	for i, p := range parts {
+		x, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil {
+			return err
+		}
+		xs[i] = x
+	}
+	b := paths.Bounds{
+		Min: paths.Vec2{math.Min(xs[0], xs[2]), math.Min(xs[1], xs[3])},
+		Max: paths.Vec2{math.Max(xs[0], xs[2]), math.Max(xs[1], xs[3])},
+	}
+	*fr = append(*fr, b)
+	return nil
+}
+
+// flagPensValue maps layer labels and colours to pens, given as
+// comma-separated name=pen pairs.
+type flagPensValue map[string]int
+
+func (fp *flagPensValue) String() string {
+	var parts []string
+	for k, v := range *fp {
+		parts = append(parts, fmt.Sprintf("%s=%d", k, v))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ",")
+}
+
+func (fp *flagPensValue) Set(s string) error {
+	if *fp == nil {
+		*fp = map[string]int{}
+	}
+	for _, part := range strings.Split(s, ",") {
+		i := strings.LastIndex(part, "=")
+		if i < 0 {
+			return fmt.Errorf("can't parse %q as name=pen", part)
+		}
+		name := strings.TrimSpace(part[:i])
+		pen, err := strconv.Atoi(strings.TrimSpace(part[i+1:]))
+		if err != nil || pen < 1 {
+			return fmt.Errorf("can't parse %q as a pen number", part[i+1:])
+		}
+		if strings.HasPrefix(name, "#") {
+			name = strings.ToLower(name)
+		}
+		(*fp)[name] = pen
+	}
+	return nil
+}
+
 var config svgtogcode.Config
 
 func init() {
 	flag.StringVar(&config.In, "in", "", "svg input file")
 	flag.StringVar(&config.Out, "out", "out.gcode", "gcode or svg output file")
 	flag.Var((*flagSizeValue)(&config.Delta), "offset", "displacement x,y of image origin from pen origin (mm)")
-	flag.Var((*flagSizeValue)(&config.Size), "size", "target size x,y of image (mm)")
+	flag.Var((*flagSizeValue)(&config.Size), "size", "target size x,y of image (mm); if unset, the svg's own size is used")
 	flag.Var((*flagSizeValue)(&config.PaperSize), "paper", "target size x,y of paper (mm)")
 	flag.BoolVar(&config.Center, "center", false, "if set, center image on paper")
 	flag.IntVar(&config.PenUp, "penup", 40, "how much to lift pen when moving")
@@ -68,7 +155,15 @@ func init() {
 	flag.BoolVar(&config.Split, "split", true, "allow paths to be split to reduce pen movement")
 	flag.BoolVar(&config.Reverse, "reverse", true, "allow paths to be drawn backwards to reduce pen movement")
 	flag.Float64Var(&config.Simplify, "simplify", 0.1, "simplify paths within this tolerance (0=disabled)")
+	flag.Float64Var(&config.Tolerance, "tolerance", 0.05, "draw curves with line segments within this distance of them (mm)")
+	flag.BoolVar(&config.Arcs, "arcs", false, "draw arcs of circles with gcode arc commands (G2/G3) rather than line segments")
 	flag.Float64Var(&config.RotateDegrees, "rotate", 0, "rotate input by this number of degrees about its center")
+	flag.Var((*flagListValue)(&config.Layers), "layers", "comma-separated labels of the Inkscape layers to draw, in file order (default all)")
+	flag.BoolVar(&config.SplitColors, "colors", false, "write one output file per stroke colour, with the colour added to the -out filename")
+	flag.Var((*flagRectsValue)(&config.KeepOut), "keepout", "rectangle x0,y0,x1,y1 on the paper (mm) to leave clear of lines; may be repeated")
+	flag.Float64Var(&config.KeepOutMargin, "keepoutmargin", 0, "also leave clear this distance around -keepout rectangles (mm)")
+	flag.Var((*flagPensValue)(&config.Pens), "pens", "comma-separated layer=pen or #rrggbb=pen pairs choosing the pen for each Inkscape layer or stroke colour")
+	flag.BoolVar(&config.Strict, "strict", false, "fail if the input uses svg features that aren't supported, rather than skipping them")
 }
 
 func usageMessage() {
@@ -81,7 +176,12 @@ func usageMessage() {
 	w("An example use is:\n\n")
 	w("    svgtocode -in drawing.svg -size 270,180 -paper 297,210 -center -penup 35 -out out.gcode -simplify 0.1\n\n")
 	w("Vector arguments, like -size and -paper take a pair of comma-separated values (no spaces).\n")
+	w("If -size is not given, the image is plotted at the physical size given in the svg file.\n")
 	w("If the -out <file> ends in .svg, the output is in svg format rather than gcode format.\n")
+	w("Lines inside -keepout rectangles are removed, for example to leave room for a title\n")
+	w("or to stay clear of clips holding the paper.\n")
+	w("-pens chooses a pen for each Inkscape layer or stroke colour, for example\n")
+	w("-pens outline=1,#ff0000=2, and the plotter pauses for a pen change between them.\n")
 	w("All distance measurements are in millimeters.\n\n")
 	w("Usage:\n")
 	flag.PrintDefaults()
@@ -102,6 +202,9 @@ func main() {
 		fail("must specify -in <svg file>")
 	}
 
+	config.Warn = func(w paths.Warning) {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", &w)
+	}
 	if err := svgtogcode.Convert(&config); err != nil {
 		fail("%v", err)
 	}
@@ -7,6 +7,7 @@ import (
 	"math"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/paulhankin/plot/gcode"
 	"github.com/paulhankin/plot/paths"
@@ -28,6 +29,44 @@ type Config struct {
 	RotateDegrees float64
 
 	Simplify float64
+
+	// Tolerance is the maximum distance (in mm, on the paper)
+	// between a curve and the line segments used to draw it.
+	// If it's zero, 0.05mm is used.
+	Tolerance float64
+
+	// If Arcs is set, arcs of circles are drawn with gcode arc
+	// commands rather than line segments.
+	Arcs bool
+
+	// If SplitColors is set, one output file is written for each
+	// stroke colour in the input, so that each can be drawn with
+	// a different pen.
+	SplitColors bool
+
+	// If Layers is set, only the Inkscape layers with these labels
+	// are drawn. They're drawn one after the other, in the order
+	// they appear in the input.
+	Layers []string
+
+	// KeepOut are rectangles on the paper (in mm) that are left
+	// clear, along with KeepOutMargin around them.
+	KeepOut       []paths.Bounds
+	KeepOutMargin float64
+
+	// Pens maps Inkscape layer labels and stroke colours (in
+	// #rrggbb form) to the pen that draws them. A path's layer
+	// takes priority over its colour. Paths that match neither
+	// are drawn with the current pen.
+	Pens map[string]int
+
+	// If Strict is set, conversion fails if the input uses
+	// features that aren't supported.
+	Strict bool
+
+	// If Warn is set, it's called for each part of the input
+	// that isn't supported and has been skipped.
+	Warn func(w paths.Warning)
 }
 
 func adjustSize(sz, ps, delta paths.Vec2, center bool, b paths.Bounds) (paths.Bounds, error) {
@@ -37,9 +76,9 @@ func adjustSize(sz, ps, delta paths.Vec2, center bool, b paths.Bounds) (paths.Bo
 		sz[0] = ow
 		sz[1] = oh
 	} else if sz[1] == 0 {
-		sz[1] = sz[1] * oh / ow
+		sz[1] = sz[0] * oh / ow
 	} else if sz[0] == 0 {
-		sz[0] = sz[0] * ow / oh
+		sz[0] = sz[1] * ow / oh
 	}
 
 	if !(math.Abs(sz[0]/sz[1]-ow/oh) < 1e-3) {
@@ -69,33 +108,125 @@ func adjustSize(sz, ps, delta paths.Vec2, center bool, b paths.Bounds) (paths.Bo
 		Max: paths.Vec2{sz[0] + delta[0], sz[1] + delta[1]},
 	}, nil
 }
-func vec2lerp(x, y paths.Vec2, s float64) paths.Vec2 {
-	return paths.Vec2{x[0]*(1-s) + y[0]*s, x[1]*(1-s) + y[1]*s}
-}
 
-func Convert(cfg *Config) error {
-	if cfg.In == "" {
-		return fmt.Errorf("input file must be specified")
+// loadLayers reads the input file, returning a single layer
+// containing all paths, one layer per stroke colour if
+// cfg.SplitColors is set, or the selected Inkscape layers if
+// cfg.Layers is set.
+func loadLayers(cfg *Config) ([]*paths.Layer, error) {
+	f, err := os.Open(cfg.In)
+	if err != nil {
+		return nil, err
 	}
-
-	ps, err := func() (*paths.Paths, error) {
-		f, err := os.Open(cfg.In)
+	defer f.Close()
+	if cfg.SplitColors && len(cfg.Layers) > 0 {
+		return nil, fmt.Errorf("can't both split colours and select layers")
+	}
+	opts := &paths.ParseOptions{Strict: cfg.Strict, Unit: "mm"}
+	warn := func(ws []paths.Warning) {
+		if cfg.Warn == nil {
+			return
+		}
+		for _, w := range ws {
+			cfg.Warn(w)
+		}
+	}
+	if cfg.SplitColors {
+		layers, ws, err := paths.ColorLayersFromSVGWithOptions(f, opts)
 		if err != nil {
 			return nil, err
 		}
-		defer f.Close()
-		ps, err := paths.FromSVG(f)
+		warn(ws)
+		return layers, nil
+	}
+	if len(cfg.Layers) > 0 {
+		layers, ws, err := paths.InkscapeLayersFromSVGWithOptions(f, opts)
 		if err != nil {
 			return nil, err
 		}
-		if cfg.RotateDegrees != 0 {
-			ps.Rotate(cfg.RotateDegrees * math.Pi / 180)
+		warn(ws)
+		want := map[string]bool{}
+		for _, n := range cfg.Layers {
+			want[n] = true
+		}
+		var r []*paths.Layer
+		for _, l := range layers {
+			if want[l.Name] {
+				r = append(r, l)
+				delete(want, l.Name)
+			}
+		}
+		for _, n := range cfg.Layers {
+			if want[n] {
+				return nil, fmt.Errorf("layer %q not found in %s", n, cfg.In)
+			}
 		}
-		return ps, nil
-	}()
+		return r, nil
+	}
+	ps, ws, err := paths.FromSVGWithOptions(f, opts)
+	if err != nil {
+		return nil, err
+	}
+	warn(ws)
+	return []*paths.Layer{{Paths: ps}}, nil
+}
+
+// layerFilename returns the output filename for the given layer.
+// Layers named by colour are written to files with the colour
+// added to the name, so out.gcode becomes out-ff0000.gcode.
+func layerFilename(out string, l *paths.Layer) string {
+	if l.Name == "" {
+		return out
+	}
+	ext := filepath.Ext(out)
+	return strings.TrimSuffix(out, ext) + "-" + strings.TrimPrefix(l.Name, "#") + ext
+}
+
+func Convert(cfg *Config) error {
+	if cfg.In == "" {
+		return fmt.Errorf("input file must be specified")
+	}
+
+	layers, err := loadLayers(cfg)
 	if err != nil {
 		return err
 	}
+	if !cfg.SplitColors {
+		var lps []*paths.Paths
+		for _, l := range layers {
+			lps = append(lps, l.Paths)
+		}
+		return convertLayers(cfg, cfg.Out, lps)
+	}
+	for _, l := range layers {
+		if err := convertLayers(cfg, layerFilename(cfg.Out, l), []*paths.Paths{l.Paths}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// assignPens sets the pen of each path from cfg.Pens.
+func assignPens(cfg *Config, ps *paths.Paths) {
+	for i := range ps.P {
+		a := &ps.P[i].Attrs
+		if pen, ok := cfg.Pens[a.Layer]; ok && a.Layer != "" {
+			a.Pen = pen
+		} else if pen, ok := cfg.Pens[a.Color]; ok && a.Color != "" {
+			a.Pen = pen
+		}
+	}
+}
+
+// prepare assigns pens, resizes, clips, erases keep-out areas, flattens,
+// simplifies and sorts the paths of a single layer.
+func prepare(cfg *Config, ps *paths.Paths) error {
+	if len(cfg.Pens) > 0 {
+		assignPens(cfg, ps)
+	}
+	if cfg.RotateDegrees != 0 {
+		ps.Rotate(cfg.RotateDegrees * math.Pi / 180)
+	}
 
 	bounds, err := adjustSize(cfg.Size, cfg.PaperSize, cfg.Delta, cfg.Center, ps.Bounds)
 	if err != nil {
@@ -104,6 +235,26 @@ func Convert(cfg *Config) error {
 
 	ps.Transform(bounds)
 	ps.Clip(ps.Bounds)
+	if len(cfg.KeepOut) > 0 {
+		var keepOut []paths.Path
+		for _, b := range cfg.KeepOut {
+			keepOut = append(keepOut, paths.RoundedRect(b, 0, 0))
+		}
+		ps.Erase(keepOut, paths.NonZero, cfg.KeepOutMargin)
+	}
+	// Curves are flattened once they're at their final size.
+	tol := cfg.Tolerance
+	if tol <= 0 {
+		tol = 0.05
+	}
+	if cfg.Arcs {
+		ps.FlattenFunc(tol, func(s *paths.Segment) bool {
+			_, _, ok := s.Arc.Circle(tol)
+			return s.Kind != paths.ArcSegment || !ok
+		})
+	} else {
+		ps.Flatten(tol)
+	}
 	if cfg.Simplify > 0 {
 		ps.Simplify(cfg.Simplify)
 	}
@@ -112,13 +263,42 @@ func Convert(cfg *Config) error {
 		Split:   cfg.Split,
 		Reverse: cfg.Reverse,
 	})
+	return nil
+}
+
+// convertLayers writes the given layers to a single output file.
+// Each layer is sorted separately, and they're drawn in order,
+// except that the paths for each pen are drawn together, so that
+// the pen is only changed once. The pens are used in the order they
+// first appear, and each pen draws its paths layer by layer.
+func convertLayers(cfg *Config, out string, layers []*paths.Paths) error {
+	ps := &paths.Paths{}
+	var pens []int
+	byPen := map[int][]paths.Path{}
+	for i, l := range layers {
+		if err := prepare(cfg, l); err != nil {
+			return err
+		}
+		if i == 0 {
+			ps.Bounds = l.Bounds
+		}
+		for _, p := range l.P {
+			if _, ok := byPen[p.Attrs.Pen]; !ok {
+				pens = append(pens, p.Attrs.Pen)
+			}
+			byPen[p.Attrs.Pen] = append(byPen[p.Attrs.Pen], p)
+		}
+	}
+	for _, pen := range pens {
+		ps.P = append(ps.P, byPen[pen]...)
+	}
 
-	gcodeOut, err := os.Create(cfg.Out)
+	gcodeOut, err := os.Create(out)
 	if err != nil {
 		return fmt.Errorf("failed to open output file: %w", err)
 	}
 
-	if filepath.Ext(cfg.Out) == ".svg" {
+	if filepath.Ext(out) == ".svg" {
 		ps.Bounds.Min = paths.Vec2{0, 0}
 		ps.Bounds.Max = cfg.PaperSize
 		err := ps.SVG(gcodeOut)
@@ -138,10 +318,28 @@ func Convert(cfg *Config) error {
 
 	gcodeWriter.Preamble()
 
+	// Paths may ask for a different pen, or a different speed.
+	pen, feed := 0, cfg.FeedRate
 	for _, p := range ps.P {
+		if p.Attrs.Pen != 0 && p.Attrs.Pen != pen {
+			pen = p.Attrs.Pen
+			gcodeWriter.ChangePen(pen)
+		}
+		f := cfg.FeedRate
+		if p.Attrs.Feed > 0 {
+			f = int(math.Round(p.Attrs.Feed))
+		}
+		if f != feed {
+			feed = f
+			gcodeWriter.FeedRate(feed)
+		}
 		for i, v := range p.V {
 			if i == 0 {
 				gcodeWriter.Move(v[0], v[1])
+			} else if p.S != nil && p.S[i-1].Kind == paths.ArcSegment {
+				arc := &p.S[i-1].Arc
+				_, ccw, _ := arc.Circle(math.Inf(1))
+				gcodeWriter.Arc(v[0], v[1], arc.Center[0]-p.V[i-1][0], arc.Center[1]-p.V[i-1][1], ccw)
 			} else {
 				gcodeWriter.Line(v[0], v[1])
 			}
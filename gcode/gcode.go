@@ -21,7 +21,8 @@ type Config struct {
 //
 // After construction, the writer should be used like this:
 //   w.Preamble()
-//   some w.Move(...) and w.Line(...) commands
+//   some w.Move(...), w.Line(...) and w.Arc(...) commands,
+//   with w.FeedRate(...) and w.ChangePen(...) between paths
 //   w.Postamble()
 //   if err := w.Flush(); err != nil {
 //      .. handle error
@@ -53,7 +54,7 @@ func (w *Writer) Preamble() {
 	w.outf("M3S%d (pen up)", w.cfg.PenUp)
 	w.outf("G21 (use mm)")
 	w.outf("G90 (use absolute coords)")
-	w.outf("G1F%d (g1 feed rate %dmm/min)", w.cfg.FeedRate, w.cfg.FeedRate)
+	w.FeedRate(w.cfg.FeedRate)
 }
 
 // Postamble writes the final part of a gcode file.
@@ -73,6 +74,31 @@ func (w *Writer) Line(x, y float64) {
 	w.outf("G1 X%.3f Y%.3f", x, y)
 }
 
+// Arc moves the downed pen to the given location along an arc of
+// a circle, whose center is offset by i, j from the current
+// location. The arc goes anticlockwise if ccw is set, and otherwise
+// clockwise.
+func (w *Writer) Arc(x, y, i, j float64, ccw bool) {
+	g := 2
+	if ccw {
+		g = 3
+	}
+	w.outf("G%d X%.3f Y%.3f I%.3f J%.3f", g, x, y, i, j)
+}
+
+// FeedRate sets the speed (in mm/min) of the lines and arcs
+// that follow.
+func (w *Writer) FeedRate(rate int) {
+	w.outf("G1F%d (g1 feed rate %dmm/min)", rate, rate)
+}
+
+// ChangePen lifts the pen and pauses, so that it can be changed
+// for the given pen.
+func (w *Writer) ChangePen(pen int) {
+	w.outf("M3S%d (pen up)", w.cfg.PenUp)
+	w.outf("M0 (change to pen %d)", pen)
+}
+
 // Flush flushes any unwritten data to the file, and returns
 // any error that may have occurred during the time the gcode
 // file was being written.
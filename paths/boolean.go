@@ -0,0 +1,147 @@
+package paths
+
+import (
+	"math"
+	"sort"
+)
+
+// combine returns the loops that bound the points where in is true,
+// given whether they're inside the polygon a and the polygon b, each
+// of which is made of paths as in ClipToPolygon. Outer boundaries go
+// anticlockwise, and the boundaries of holes go clockwise, so that
+// the loops enclose the result with either fill rule. Where parts of
+// the result touch at a point, they get separate loops, so no loop
+// goes through the same point twice. The loops have the attributes
+// of the first path of a, or of b if a is empty.
+func combine(a, b []Path, rule FillRule, in func(inA, inB bool) bool) []Path {
+	ra, rb := polygonArea(a, rule), polygonArea(b, rule)
+	var es [][2]Vec2
+	for _, e := range rb.edges(ra.edges(nil)) {
+		if e[0] != e[1] {
+			es = append(es, e)
+		}
+	}
+	inside := func(v Vec2) bool {
+		return in(ra.contains(v), rb.contains(v))
+	}
+
+	// Every edge is split wherever another edge crosses it, or starts
+	// or ends on it, so that each piece is either all on the boundary
+	// of the result or all off it. A piece is on the boundary if the
+	// result is on one side of it and not the other, and it's turned
+	// round if need be so that the result is on its left.
+	type piece struct {
+		a, b Vec2
+	}
+	// Only the edges near each one are checked, using a grid.
+	boxes := make([]Bounds, len(es))
+	for i, e := range es {
+		boxes[i] = segmentBounds(e[0], e[1], 1e-9)
+	}
+	g := newBoxGrid(boxes)
+	var pieces []piece
+	var ts []float64
+	var near []int
+	var fs [][2]Vec2
+	for i, e := range es {
+		near = g.near(near[:0], boxes[i].Min, boxes[i].Max)
+		fs = fs[:0]
+		for _, j := range near {
+			fs = append(fs, es[j])
+		}
+		ts = segmentCrossings(ts[:0], e[0], e[1], fs)
+		for _, f := range fs {
+			ts = segmentVertices(ts, e[0], e[1], f[:])
+		}
+		ts = append(ts, 0, 1)
+		sort.Float64s(ts)
+		for k := 1; k < len(ts); k++ {
+			if ts[k]-ts[k-1] < 1e-12 {
+				continue
+			}
+			p, q := e[0].Lerp(e[1], ts[k-1]), e[0].Lerp(e[1], ts[k])
+			u := q.Sub(p)
+			l := u.Len()
+			if l == 0 {
+				continue
+			}
+			// The sides are tested close to the middle of the piece,
+			// but clear of any edges that run along it.
+			m := p.Lerp(q, 0.5)
+			n := Vec2{-u[1], u[0]}.Scale(math.Min(1e-7, l/8) / l)
+			left, right := inside(m.Add(n)), inside(m.Sub(n))
+			if left && !right {
+				pieces = append(pieces, piece{p, q})
+			} else if right && !left {
+				pieces = append(pieces, piece{q, p})
+			}
+		}
+	}
+
+	// Where edges run along each other, there's a piece for each of
+	// them, and only the first is kept.
+	boxes = boxes[:0]
+	for _, p := range pieces {
+		boxes = append(boxes, segmentBounds(p.a, p.b, 1e-9))
+	}
+	g = newBoxGrid(boxes)
+	var kept [][2]Vec2
+	for i, p := range pieces {
+		m, u := p.a.Lerp(p.b, 0.5), p.b.Sub(p.a)
+		dup := false
+		near = g.near(near[:0], m, m)
+		for _, j := range near {
+			o := pieces[j]
+			v := o.b.Sub(o.a)
+			if j < i && u[0]*v[0]+u[1]*v[1] > 0 && segmentDist(m, o.a, o.b) < 1e-9 {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			kept = append(kept, [2]Vec2{p.a, p.b})
+		}
+	}
+
+	loops := pieceLoops(kept)
+	var attrs Attrs
+	if len(a) > 0 {
+		attrs = a[0].Attrs
+	} else if len(b) > 0 {
+		attrs = b[0].Attrs
+	}
+	for i := range loops {
+		loops[i].Attrs = attrs
+	}
+	return loops
+}
+
+// Union returns closed paths that enclose the area inside either of
+// the polygons a and b. Each polygon is made of one or more paths,
+// as in ClipToPolygon, and the rule decides which parts of it are
+// inside. The result goes anticlockwise round the outside of each
+// piece, and clockwise round its holes, so it can be hatched,
+// offset or clipped to with either fill rule. Curves are flattened
+// to within 0.05 of the curve, and the result has the attributes of
+// the first path of a, or of b if a is empty.
+func Union(a, b []Path, rule FillRule) []Path {
+	return combine(a, b, rule, func(inA, inB bool) bool { return inA || inB })
+}
+
+// Intersection returns closed paths that enclose the area inside
+// both of the polygons a and b, which are treated as in Union.
+func Intersection(a, b []Path, rule FillRule) []Path {
+	return combine(a, b, rule, func(inA, inB bool) bool { return inA && inB })
+}
+
+// Difference returns closed paths that enclose the area inside the
+// polygon a but not inside b, which are treated as in Union.
+func Difference(a, b []Path, rule FillRule) []Path {
+	return combine(a, b, rule, func(inA, inB bool) bool { return inA && !inB })
+}
+
+// Xor returns closed paths that enclose the area inside exactly one
+// of the polygons a and b, which are treated as in Union.
+func Xor(a, b []Path, rule FillRule) []Path {
+	return combine(a, b, rule, func(inA, inB bool) bool { return inA != inB })
+}
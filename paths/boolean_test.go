@@ -0,0 +1,161 @@
+package paths
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// booleanOps are the boolean operations, and which points they keep.
+var booleanOps = []struct {
+	name string
+	op   func(a, b []Path, rule FillRule) []Path
+	in   func(inA, inB bool) bool
+}{
+	{"union", Union, func(inA, inB bool) bool { return inA || inB }},
+	{"intersection", Intersection, func(inA, inB bool) bool { return inA && inB }},
+	{"difference", Difference, func(inA, inB bool) bool { return inA && !inB }},
+	{"xor", Xor, func(inA, inB bool) bool { return inA != inB }},
+}
+
+// checkBoolean checks that the result of a boolean operation is closed
+// loops that enclose the right points with either fill rule, away
+// from the edges of the polygons, and that the loops don't go
+// through any vertex twice, or have any vertices in line with their
+// neighbours.
+func checkBoolean(t *testing.T, desc string, a, b []Path, rule FillRule, got []Path, in func(inA, inB bool) bool) {
+	ra, rb := polygonArea(a, rule), polygonArea(b, rule)
+	es := rb.edges(ra.edges(nil))
+	for _, p := range got {
+		if !p.Closed || p.V[0] != p.V[len(p.V)-1] {
+			t.Errorf("%s: path %v isn't closed", desc, p.V)
+		}
+		n := len(p.V) - 1
+		for i := 0; i < n; i++ {
+			v := p.V[i]
+			if segmentDist(v, p.V[(i+n-1)%n], p.V[i+1]) < 1e-9 {
+				t.Errorf("%s: path %v has %v in line with its neighbours", desc, p.V, v)
+			}
+			for _, w := range p.V[i+1 : n] {
+				if v.Dist(w) < 1e-7 {
+					t.Errorf("%s: path %v goes through %v twice", desc, p.V, v)
+				}
+			}
+		}
+	}
+	nonZero, evenOdd := polygonArea(got, NonZero), polygonArea(got, EvenOdd)
+	for x := -12.0; x <= 22; x += 0.37 {
+		for y := -12.0; y <= 22; y += 0.37 {
+			v := Vec2{x, y}
+			if edgeDist(v, es) < 1e-6 {
+				continue
+			}
+			want := in(ra.contains(v), rb.contains(v))
+			if nonZero.contains(v) != want || evenOdd.contains(v) != want {
+				t.Errorf("%s: %v is inside %v with the non-zero rule and %v with the even-odd rule, want %v", desc, v, nonZero.contains(v), evenOdd.contains(v), want)
+				return
+			}
+		}
+	}
+}
+
+func TestBoolean(t *testing.T) {
+	square := rect(0, 0, 10, 10)
+	cases := []struct {
+		desc string
+		a, b []Path
+		rule FillRule
+		// The areas of the union, intersection, difference and xor.
+		areas [4]float64
+	}{
+		{"overlapping", []Path{square}, []Path{rect(5, 5, 15, 15)}, NonZero, [4]float64{175, 25, 75, 150}},
+		{"separate", []Path{square}, []Path{rect(12, 0, 20, 10)}, NonZero, [4]float64{180, 0, 100, 180}},
+		{"side by side", []Path{square}, []Path{rect(10, 0, 20, 10)}, NonZero, [4]float64{200, 0, 100, 200}},
+		{"sharing part of a side", []Path{square}, []Path{rect(10, 5, 20, 15)}, NonZero, [4]float64{200, 0, 100, 200}},
+		{"touching corners", []Path{square}, []Path{rect(10, 10, 20, 20)}, NonZero, [4]float64{200, 0, 100, 200}},
+		{"touching at a vertex", []Path{square}, []Path{{V: []Vec2{{10, 5}, {20, 0}, {20, 10}, {10, 5}}, Closed: true}}, NonZero, [4]float64{150, 0, 100, 150}},
+		{"hole touching the outside", []Path{square}, []Path{{V: []Vec2{{0, 5}, {5, 2}, {5, 8}, {0, 5}}, Closed: true}}, NonZero, [4]float64{100, 15, 85, 85}},
+		{"sharing a side", []Path{square}, []Path{rect(0, 0, 10, 5)}, NonZero, [4]float64{100, 50, 50, 50}},
+		{"the same", []Path{square}, []Path{square}, NonZero, [4]float64{100, 100, 0, 0}},
+		{"the same, the other way round", []Path{square}, []Path{reverse(square)}, NonZero, [4]float64{100, 100, 0, 0}},
+		{"inside", []Path{square}, []Path{rect(2, 2, 8, 8)}, NonZero, [4]float64{100, 36, 64, 64}},
+		{"with a hole", []Path{square, rect(2, 2, 8, 8)}, []Path{rect(5, -5, 15, 15)}, EvenOdd, [4]float64{232, 32, 32, 200}},
+		{"filling a hole", []Path{square, reverse(rect(2, 2, 8, 8))}, []Path{rect(2, 2, 8, 8)}, NonZero, [4]float64{100, 0, 64, 100}},
+		{"crossing itself", []Path{{V: []Vec2{{0, 0}, {10, 10}, {10, 0}, {0, 10}, {0, 0}}, Closed: true}}, []Path{rect(0, 0, 10, 5)}, NonZero, [4]float64{75, 25, 25, 50}},
+		{"empty", []Path{square}, nil, NonZero, [4]float64{100, 0, 100, 100}},
+	}
+	for _, c := range cases {
+		c.a = append([]Path{}, c.a...)
+		if len(c.a) > 0 {
+			c.a[0].Attrs = Attrs{Pen: 2}
+		}
+		for i, o := range booleanOps {
+			desc := fmt.Sprintf("%s %s", c.desc, o.name)
+			got := o.op(c.a, c.b, c.rule)
+			if a := netArea(got); math.Abs(a-c.areas[i]) > 1e-6 {
+				t.Errorf("%s: area %g, want %g", desc, a, c.areas[i])
+			}
+			for _, p := range got {
+				if p.Attrs != (Attrs{Pen: 2}) {
+					t.Errorf("%s: path has attributes %+v, want those of the first path", desc, p.Attrs)
+				}
+			}
+			checkBoolean(t, desc, c.a, c.b, c.rule, got, o.in)
+		}
+	}
+
+	// The same shapes give a single loop, whichever way they go.
+	for _, b := range []Path{square, reverse(square)} {
+		if got := Union([]Path{square}, []Path{b}, NonZero); len(got) != 1 || len(got[0].V) != 5 {
+			t.Errorf("union of a square with itself is %v, want the square", got)
+		}
+	}
+	// Squares side by side give a rectangle, without the vertices
+	// where they meet.
+	if got := Union([]Path{square}, []Path{rect(10, 0, 20, 10)}, NonZero); len(got) != 1 || len(got[0].V) != 5 {
+		t.Errorf("union of squares side by side is %v, want a rectangle", got)
+	}
+	// Overlapping squares give two separate pieces that only touch
+	// at their corners.
+	if got := Xor([]Path{square}, []Path{rect(5, 5, 15, 15)}, NonZero); len(got) != 2 || math.Abs(loopArea(got[0].V)-75) > 1e-9 || math.Abs(loopArea(got[1].V)-75) > 1e-9 {
+		t.Errorf("xor of overlapping squares is %v, want two loops of area 75", got)
+	}
+	// Curves are flattened.
+	got := Union([]Path{Circle(Vec2{}, 5)}, []Path{Circle(Vec2{5, 0}, 5)}, NonZero)
+	if len(got) != 1 || got[0].curved() {
+		t.Errorf("union of two circles is %v, want one flattened loop", got)
+	}
+}
+
+func TestBooleanRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 30; i++ {
+		a := []Path{randomPolygon(r, Vec2{}, 4+r.Intn(12))}
+		b := []Path{randomPolygon(r, Vec2{r.Float64() * 10, r.Float64() * 10}, 4+r.Intn(12))}
+		if r.Intn(2) == 0 {
+			// b is a with a vertex moved out, so most of their edges
+			// run along each other.
+			b[0] = a[0].clone()
+			b[0].V[1] = b[0].V[1].Scale(1.5)
+		}
+		var areas [4]float64
+		for k, o := range booleanOps {
+			desc := fmt.Sprintf("polygon %d %s", i, o.name)
+			got := o.op(a, b, NonZero)
+			areas[k] = netArea(got)
+			checkBoolean(t, desc, a, b, NonZero, got, o.in)
+
+			// The result can be offset.
+			ps := &Paths{P: got}
+			ps.Offset(0.5, NonZero, RoundJoin)
+			if len(got) > 0 && netArea(ps.P) < areas[k] {
+				t.Errorf("%s: offset has area %g, less than %g", desc, netArea(ps.P), areas[k])
+			}
+		}
+		areaA, areaB := netArea(a), netArea(b)
+		if math.Abs(areas[0]+areas[1]-areaA-areaB) > 1e-6 || math.Abs(areas[2]+areas[1]-areaA) > 1e-6 || math.Abs(areas[3]+areas[1]-areas[0]) > 1e-6 {
+			t.Errorf("polygon %d: union, intersection, difference and xor have areas %v, with polygons of area %g and %g", i, areas, areaA, areaB)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package paths
+
+// A Builder makes paths from a sequence of drawing commands, like
+// the commands of SVG path data. The zero Builder is ready to use,
+// with its current point at the origin.
+type Builder struct {
+	ps    Paths
+	pos   Vec2 // the current point
+	start Vec2 // where the current path starts
+	open  bool // whether the last path in ps is being extended
+}
+
+// Pos returns the current point, which is where the next segment
+// starts.
+func (b *Builder) Pos() Vec2 {
+	return b.pos
+}
+
+// draw extends the current path with a segment of shape s that
+// goes to v, starting a new path if needed.
+func (b *Builder) draw(v Vec2, s Segment) {
+	if !b.open {
+		b.ps.P = append(b.ps.P, Path{V: []Vec2{b.pos}})
+		b.open = true
+	}
+	b.ps.P[len(b.ps.P)-1].add(v, s)
+	b.pos = v
+}
+
+// MoveTo starts a new path at v.
+func (b *Builder) MoveTo(v Vec2) {
+	b.pos, b.start, b.open = v, v, false
+}
+
+// LineTo draws a straight line from the current point to v.
+func (b *Builder) LineTo(v Vec2) {
+	b.draw(v, Segment{})
+}
+
+// QuadTo draws a quadratic bezier from the current point to v,
+// with control point c.
+func (b *Builder) QuadTo(c, v Vec2) {
+	b.draw(v, Segment{Kind: QuadSegment, C: [2]Vec2{c}})
+}
+
+// CubicTo draws a cubic bezier from the current point to v,
+// with control points c1 and c2.
+func (b *Builder) CubicTo(c1, c2, v Vec2) {
+	b.draw(v, Segment{Kind: CubicSegment, C: [2]Vec2{c1, c2}})
+}
+
+// ArcTo draws an elliptical arc from the current point to v, like
+// the SVG arc command. The ellipse has radii rx and ry, and its
+// x axis is rotated by phi (in radians). Of the four arcs that
+// fit, large picks one that turns through more than half a turn,
+// and sweep picks one that goes in the direction of increasing
+// angles. If a radius is zero, the arc is a straight line, and if
+// v is the current point, nothing is drawn.
+func (b *Builder) ArcTo(rx, ry, phi float64, large, sweep bool, v Vec2) {
+	if v == b.pos {
+		return
+	}
+	b.draw(v, arcSegment(Identity(), b.pos, rx, ry, phi, large, sweep, v))
+}
+
+// Close draws a straight line back to the start of the current
+// path, if it's not already there, and ends the path, which is
+// marked as Closed. The next segment starts a new path from the
+// same point.
+func (b *Builder) Close() {
+	if b.open {
+		if b.pos != b.start {
+			b.draw(b.start, Segment{})
+		}
+		b.ps.P[len(b.ps.P)-1].Closed = true
+	}
+	b.MoveTo(b.start)
+}
+
+// RelMoveTo is like MoveTo, but v is relative to the current point.
+func (b *Builder) RelMoveTo(v Vec2) {
+	b.MoveTo(b.pos.Add(v))
+}
+
+// RelLineTo is like LineTo, but v is relative to the current point.
+func (b *Builder) RelLineTo(v Vec2) {
+	b.LineTo(b.pos.Add(v))
+}
+
+// RelQuadTo is like QuadTo, but c and v are relative to the
+// current point.
+func (b *Builder) RelQuadTo(c, v Vec2) {
+	b.QuadTo(b.pos.Add(c), b.pos.Add(v))
+}
+
+// RelCubicTo is like CubicTo, but c1, c2 and v are relative to
+// the current point.
+func (b *Builder) RelCubicTo(c1, c2, v Vec2) {
+	b.CubicTo(b.pos.Add(c1), b.pos.Add(c2), b.pos.Add(v))
+}
+
+// RelArcTo is like ArcTo, but v is relative to the current point.
+func (b *Builder) RelArcTo(rx, ry, phi float64, large, sweep bool, v Vec2) {
+	b.ArcTo(rx, ry, phi, large, sweep, b.pos.Add(v))
+}
+
+// Add adds copies of the given paths, for example shapes from
+// Circle or Star. The current point doesn't change.
+func (b *Builder) Add(p ...Path) {
+	for i := range p {
+		b.ps.P = append(b.ps.P, p[i].clone())
+	}
+	b.open = false
+}
+
+// Paths returns a copy of the paths drawn so far, with bounds
+// that exactly contain them.
+func (b *Builder) Paths() *Paths {
+	ps := &Paths{}
+	for i := range b.ps.P {
+		ps.P = append(ps.P, b.ps.P[i].clone())
+	}
+	ps.TightenBounds()
+	return ps
+}
@@ -0,0 +1,73 @@
+package paths
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBuilder(t *testing.T) {
+	var b Builder
+	b.MoveTo(Vec2{10, 10})
+	b.LineTo(Vec2{20, 10})
+	b.RelLineTo(Vec2{0, 10})
+	b.Close()
+	// Drawing after Close starts a new path from the same point.
+	b.RelQuadTo(Vec2{5, -10}, Vec2{10, 0})
+	b.RelCubicTo(Vec2{0, 10}, Vec2{10, 10}, Vec2{10, 0})
+	b.MoveTo(Vec2{50, 0})
+	b.RelArcTo(10, 10, 0, false, true, Vec2{20, 0})
+	// Moving twice, and arcs that go nowhere, don't make paths.
+	b.MoveTo(Vec2{0, 0})
+	b.MoveTo(Vec2{100, 100})
+	b.ArcTo(5, 5, 0, false, false, Vec2{100, 100})
+
+	if got, want := b.Pos(), (Vec2{100, 100}); got != want {
+		t.Errorf("Pos() = %v, want %v", got, want)
+	}
+	ps := b.Paths()
+	want := []Path{
+		{V: []Vec2{{10, 10}, {20, 10}, {20, 20}, {10, 10}}},
+		{
+			V: []Vec2{{10, 10}, {20, 10}, {30, 10}},
+			S: []Segment{
+				{Kind: QuadSegment, C: [2]Vec2{{15, 0}}},
+				{Kind: CubicSegment, C: [2]Vec2{{20, 20}, {30, 20}}},
+			},
+		},
+		{
+			V: []Vec2{{50, 0}, {70, 0}},
+			S: []Segment{{Kind: ArcSegment, Arc: Arc{Center: Vec2{60, 0}, U: Vec2{10, 0}, V: Vec2{0, 10}, Start: math.Pi, Sweep: math.Pi}}},
+		},
+	}
+	if !shapesNear(ps.P, want, 1e-9) {
+		t.Errorf("built paths %v, want %v", ps.P, want)
+	}
+	for i, p := range ps.P {
+		if p.Closed != (i == 0) {
+			t.Errorf("built path %d has Closed %v, want %v", i, p.Closed, i == 0)
+		}
+	}
+	// The arc bulges upwards, since positive angles turn x towards y
+	// and the arc goes from angle pi to 2pi.
+	wantBounds := Bounds{Min: Vec2{10, -10}, Max: Vec2{70, 20}}
+	if ps.Bounds.Min.Dist(wantBounds.Min) > 1e-9 || ps.Bounds.Max.Dist(wantBounds.Max) > 1e-9 {
+		t.Errorf("built paths have bounds %v, want %v", ps.Bounds, wantBounds)
+	}
+}
+
+func TestBuilderAdd(t *testing.T) {
+	var b Builder
+	b.MoveTo(Vec2{0, 0})
+	b.LineTo(Vec2{10, 0})
+	b.Add(Circle(Vec2{50, 50}, 5))
+	// The current path ended when the circle was added.
+	b.LineTo(Vec2{10, 10})
+	ps := b.Paths()
+	if len(ps.P) != 3 {
+		t.Fatalf("built %d paths, want 3", len(ps.P))
+	}
+	want := Path{V: []Vec2{{10, 0}, {10, 10}}}
+	if !shapesNear(ps.P[2:], []Path{want}, 0) {
+		t.Errorf("last path is %v, want %v", ps.P[2], want)
+	}
+}
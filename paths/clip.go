@@ -74,7 +74,7 @@ func clipPath(p Path, b Bounds) []Path {
 			continue
 		}
 		if v0 != p.V[i-1] || !cont {
-			parts = append(parts, Path{})
+			parts = append(parts, Path{Attrs: p.Attrs})
 			curPath = &parts[len(parts)-1]
 			curPath.V = append(curPath.V, v0)
 		}
@@ -93,13 +93,30 @@ func clipPath(p Path, b Bounds) []Path {
 	return parts[:j]
 }
 
-// Clip removes all line segments outside the given bounds.
+// Clip removes all parts of the paths outside the given bounds.
 // If a path crosses the bounds, it's broken into multiple paths.
+// Curves are split where they cross the bounds. Closed paths that
+// are cut are no longer closed.
 func (ps *Paths) Clip(b Bounds) {
 	var result []Path
 	for _, p := range ps.P {
-		parts := clipPath(p, b)
-		result = append(result, parts...)
+		if p.curved() {
+			result = append(result, clipToRegion([]Path{p}, boundsRegion(b))...)
+			continue
+		}
+		result = append(result, joinSeam(&p, clipPath(p, b))...)
 	}
 	ps.P = result
 }
+
+// ClipToPolygon removes all parts of the paths outside the area
+// enclosed by the polygon. The polygon is made of one or more
+// paths, so that it can have holes or separate pieces, and each of
+// them is joined back to its start. Curves in the polygon are
+// flattened to within 0.05 of the curve. The rule decides which
+// parts of a polygon that overlaps or intersects itself are inside.
+// As with Clip, paths that cross the edge of the polygon are broken
+// into multiple paths.
+func (ps *Paths) ClipToPolygon(poly []Path, rule FillRule) {
+	ps.P = clipToRegion(ps.P, polygonArea(poly, rule))
+}
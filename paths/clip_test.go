@@ -1,6 +1,7 @@
 package paths
 
 import (
+	"math"
 	"reflect"
 	"testing"
 )
@@ -79,3 +80,219 @@ func TestClip(t *testing.T) {
 	}
 
 }
+
+func TestClipCurves(t *testing.T) {
+	circle := Path{
+		V: []Vec2{{10, 0}, {-10, 0}, {10, 0}},
+		S: []Segment{
+			{Kind: ArcSegment, Arc: Arc{U: Vec2{10, 0}, V: Vec2{0, 10}, Sweep: math.Pi}},
+			{Kind: ArcSegment, Arc: Arc{U: Vec2{10, 0}, V: Vec2{0, 10}, Start: math.Pi, Sweep: math.Pi}},
+		},
+	}
+	cubic := Path{
+		V: []Vec2{{0, 0}, {30, 0}},
+		S: []Segment{{Kind: CubicSegment, C: [2]Vec2{{0, 40}, {30, 40}}}},
+	}
+	t0 := (1 - 1/math.Sqrt2) / 2
+	cubicPt := func(t float64) Vec2 { return cubic.S[0].point(cubic.V[0], cubic.V[1], t) }
+	cases := []struct {
+		desc   string
+		path   Path
+		bounds Bounds
+		want   []Path
+	}{
+		{
+			desc:   "upper half of circle",
+			path:   circle,
+			bounds: Bounds{Min: Vec2{-20, 0}, Max: Vec2{20, 20}},
+			want:   []Path{{V: []Vec2{{10, 0}, {-10, 0}}, S: circle.S[:1]}},
+		},
+		{
+			desc:   "right half of circle, joined across the start",
+			path:   circle,
+			bounds: Bounds{Min: Vec2{0, -20}, Max: Vec2{20, 20}},
+			want: []Path{{
+				V: []Vec2{{0, -10}, {10, 0}, {0, 10}},
+				S: []Segment{
+					{Kind: ArcSegment, Arc: Arc{U: Vec2{10, 0}, V: Vec2{0, 10}, Start: 1.5 * math.Pi, Sweep: math.Pi / 2}},
+					{Kind: ArcSegment, Arc: Arc{U: Vec2{10, 0}, V: Vec2{0, 10}, Sweep: math.Pi / 2}},
+				},
+			}},
+		},
+		{
+			desc:   "top of cubic cut off",
+			path:   cubic,
+			bounds: Bounds{Min: Vec2{-10, -10}, Max: Vec2{40, 15}},
+			// The curve's height is 120t(1-t), which is 15 at t0 and 1-t0.
+			want: []Path{
+				{V: []Vec2{{0, 0}, cubicPt(t0)}, S: []Segment{cubic.S[0].split(cubic.V[0], cubic.V[1], 0, t0)}},
+				{V: []Vec2{cubicPt(1 - t0), {30, 0}}, S: []Segment{cubic.S[0].split(cubic.V[0], cubic.V[1], 1-t0, 1)}},
+			},
+		},
+		{
+			desc:   "curve inside bounds",
+			path:   cubic,
+			bounds: Bounds{Min: Vec2{-10, -10}, Max: Vec2{40, 40}},
+			want:   []Path{cubic},
+		},
+	}
+	for _, c := range cases {
+		ps := &Paths{P: []Path{c.path.clone()}}
+		ps.Clip(c.bounds)
+		if !shapesNear(ps.P, c.want, 1e-6) {
+			t.Errorf("%s: got %v, want %v", c.desc, ps.P, c.want)
+		}
+		for _, p := range ps.P {
+			for _, s := range p.S {
+				if s.Kind != c.path.S[0].Kind {
+					t.Errorf("%s: clipped curve has a segment of kind %v, want %v", c.desc, s.Kind, c.path.S[0].Kind)
+				}
+			}
+		}
+	}
+}
+
+func TestClipClosed(t *testing.T) {
+	square := Path{V: []Vec2{{0, 0}, {10, 0}, {10, 10}, {0, 10}, {0, 0}}, Closed: true}
+	circle := Circle(Vec2{0, 0}, 10)
+	cases := []struct {
+		desc       string
+		path       Path
+		bounds     Bounds
+		wantParts  int
+		wantClosed bool
+	}{
+		{"square inside", square, Bounds{Min: Vec2{-5, -5}, Max: Vec2{15, 15}}, 1, true},
+		// The pieces either side of the seam at (0, 0) are joined.
+		{"square cut", square, Bounds{Min: Vec2{-5, -5}, Max: Vec2{15, 5}}, 1, false},
+		{"square cut twice", square, Bounds{Min: Vec2{-5, 3}, Max: Vec2{15, 5}}, 2, false},
+		{"circle inside", circle, Bounds{Min: Vec2{-20, -20}, Max: Vec2{20, 20}}, 1, true},
+		{"circle cut", circle, Bounds{Min: Vec2{-20, -20}, Max: Vec2{20, 5}}, 1, false},
+	}
+	for _, c := range cases {
+		ps := &Paths{P: []Path{c.path.clone()}}
+		ps.Clip(c.bounds)
+		if len(ps.P) != c.wantParts {
+			t.Errorf("%s: clipped into %d parts %v, want %d", c.desc, len(ps.P), ps.P, c.wantParts)
+			continue
+		}
+		if ps.P[0].Closed != c.wantClosed {
+			t.Errorf("%s: clipped path closed is %v, want %v", c.desc, ps.P[0].Closed, c.wantClosed)
+		}
+	}
+	ps := &Paths{P: []Path{square.clone()}}
+	ps.Clip(Bounds{Min: Vec2{-5, -5}, Max: Vec2{15, 5}})
+	want := []Path{{V: []Vec2{{0, 5}, {0, 0}, {10, 0}, {10, 5}}}}
+	if !reflect.DeepEqual(ps.P, want) {
+		t.Errorf("cut square is %v, want %v", ps.P, want)
+	}
+}
+
+func TestClipToPolygon(t *testing.T) {
+	// A pentagram, drawn by joining every other vertex of a pentagon.
+	var pentagram Path
+	for i := 0; i <= 5; i++ {
+		a := math.Pi/2 + 4*math.Pi*float64(i)/5
+		pentagram.V = append(pentagram.V, Vec2{10 * math.Cos(a), 10 * math.Sin(a)})
+	}
+	x3 := math.Sqrt(91)
+	cases := []struct {
+		desc string
+		poly []Path
+		rule FillRule
+		path Path
+		want []Path
+		tol  float64
+	}{
+		{"square", []Path{rect(-10, -10, 10, 10)}, NonZero, line(-20, 0, 20, 0), []Path{line(-10, 0, 10, 0)}, 1e-9},
+		{"diamond", []Path{Polygon(Vec2{}, 4, 10, 0)}, EvenOdd, line(-20, 5, 20, 5), []Path{line(-5, 5, 5, 5)}, 1e-9},
+		{"open polygon is joined up", []Path{{V: []Vec2{{-10, -10}, {10, -10}, {10, 10}}}}, NonZero, line(-20, 0, 20, 0), []Path{line(0, 0, 10, 0)}, 1e-9},
+		{"circle", []Path{Circle(Vec2{}, 10)}, NonZero, line(-20, 3, 20, 3), []Path{line(-x3, 3, x3, 3)}, 0.1},
+		{
+			desc: "hole, even-odd",
+			poly: []Path{rect(-10, -10, 10, 10), rect(-5, -5, 5, 5)},
+			rule: EvenOdd,
+			path: line(-20, 0, 20, 0),
+			want: []Path{line(-10, 0, -5, 0), line(5, 0, 10, 0)},
+			tol:  1e-9,
+		},
+		{
+			desc: "hole the same way round, nonzero",
+			poly: []Path{rect(-10, -10, 10, 10), rect(-5, -5, 5, 5)},
+			rule: NonZero,
+			path: line(-20, 0, 20, 0),
+			want: []Path{line(-10, 0, 10, 0)},
+			tol:  1e-9,
+		},
+		{
+			desc: "hole the other way round, nonzero",
+			poly: []Path{rect(-10, -10, 10, 10), reverse(rect(-5, -5, 5, 5))},
+			rule: NonZero,
+			path: line(-20, 0, 20, 0),
+			want: []Path{line(-10, 0, -5, 0), line(5, 0, 10, 0)},
+			tol:  1e-9,
+		},
+		{
+			desc: "overlapping squares, even-odd",
+			poly: []Path{rect(-10, -10, 5, 10), rect(-5, -10, 10, 10)},
+			rule: EvenOdd,
+			path: line(-20, 0, 20, 0),
+			want: []Path{line(-10, 0, -5, 0), line(5, 0, 10, 0)},
+			tol:  1e-9,
+		},
+		{
+			desc: "curves are kept",
+			poly: []Path{rect(0, -20, 20, 20)},
+			rule: NonZero,
+			path: Circle(Vec2{}, 10),
+			want: []Path{{
+				V: []Vec2{{0, -10}, {10, 0}, {0, 10}},
+				S: []Segment{
+					{Kind: ArcSegment, Arc: Arc{U: Vec2{10, 0}, V: Vec2{0, 10}, Start: 1.5 * math.Pi, Sweep: math.Pi / 2}},
+					{Kind: ArcSegment, Arc: Arc{U: Vec2{10, 0}, V: Vec2{0, 10}, Sweep: math.Pi / 2}},
+				},
+			}},
+			tol: 1e-6,
+		},
+	}
+	for _, c := range cases {
+		ps := &Paths{P: []Path{c.path.clone()}}
+		ps.ClipToPolygon(c.poly, c.rule)
+		if !shapesNear(ps.P, c.want, c.tol) {
+			t.Errorf("%s: got %v, want %v", c.desc, ps.P, c.want)
+		}
+	}
+
+	// The middle of a pentagram is only inside with the nonzero rule.
+	for _, rule := range []FillRule{EvenOdd, NonZero} {
+		ps := &Paths{P: []Path{line(-20, 0, 20, 0)}}
+		ps.ClipToPolygon([]Path{pentagram}, rule)
+		want := 2
+		if rule == NonZero {
+			want = 1
+		}
+		if len(ps.P) != want {
+			t.Errorf("pentagram with rule %v: clipped into %v, want %d parts", rule, ps.P, want)
+		}
+	}
+
+	// A vertex that touches the edge of the polygon isn't cut off.
+	triangle := []Path{{V: []Vec2{{0, 0}, {3, 1}, {0, 10}, {0, 0}}}}
+	for _, x := range []float64{0.655, 0.729} {
+		ps := &Paths{P: []Path{{V: []Vec2{{x - 1, x/3 + 2}, {x, x / 3}, {x + 0.5, x/3 + 3}}}}}
+		ps.ClipToPolygon(triangle, NonZero)
+		if len(ps.P) != 1 || len(ps.P[0].V) != 3 {
+			t.Errorf("path touching the edge at x=%v: clipped to %v, want one path with 3 points", x, ps.P)
+		}
+	}
+
+	// Closed paths inside the polygon stay closed, and attributes
+	// are kept.
+	in := rect(-1, -1, 1, 1)
+	in.Attrs = Attrs{Layer: "inside", Pen: 2}
+	ps := &Paths{P: []Path{in.clone()}}
+	ps.ClipToPolygon([]Path{Polygon(Vec2{}, 6, 5, 0)}, EvenOdd)
+	if !reflect.DeepEqual(ps.P, []Path{in}) {
+		t.Errorf("closed path inside polygon clipped to %v, want %v", ps.P, []Path{in})
+	}
+}
@@ -0,0 +1,213 @@
+package paths
+
+import "math"
+
+// curveTolerance is the default maximum distance (in millimeters)
+// between a curve and the line segments used to draw it.
+const curveTolerance = 0.05
+
+// maxCurveDepth limits how many times a curve is subdivided when
+// it's flattened.
+const maxCurveDepth = 16
+
+// flattenCubic approximates the cubic bezier with control points
+// p0, p1, p2, p3 by line segments, and appends the vertices after p0
+// to vs. No point on the curve is further than tol from the segments.
+// The curve is subdivided most where it bends most: a piece is drawn
+// as a single segment once the second differences of its control
+// points show it's within tolerance of its chord (Wang's formula).
+func flattenCubic(vs []Vec2, tol float64, p0, p1, p2, p3 Vec2) []Vec2 {
+	return flattenCubicDepth(vs, tol, p0, p1, p2, p3, 0)
+}
+
+func flattenCubicDepth(vs []Vec2, tol float64, p0, p1, p2, p3 Vec2, depth int) []Vec2 {
+	dd := math.Max(
+		math.Hypot(p0[0]-2*p1[0]+p2[0], p0[1]-2*p1[1]+p2[1]),
+		math.Hypot(p1[0]-2*p2[0]+p3[0], p1[1]-2*p2[1]+p3[1]))
+	if 0.75*dd <= tol || depth >= maxCurveDepth {
+		return append(vs, p3)
+	}
+	// Split the curve in two with de Casteljau's algorithm.
+	p01, p12, p23 := p0.Lerp(p1, 0.5), p1.Lerp(p2, 0.5), p2.Lerp(p3, 0.5)
+	p012, p123 := p01.Lerp(p12, 0.5), p12.Lerp(p23, 0.5)
+	m := p012.Lerp(p123, 0.5)
+	vs = flattenCubicDepth(vs, tol, p0, p01, p012, m, depth+1)
+	return flattenCubicDepth(vs, tol, m, p123, p23, p3, depth+1)
+}
+
+// An Arc is part of an ellipse: the points
+// Center + U cos(t) + V sin(t), for t from Start to Start+Sweep
+// (in radians). A negative Sweep goes backwards. U and V are
+// conjugate semi-diameters of the ellipse (for a circle, they're
+// perpendicular radii), so an Arc remains one under any affine
+// transformation.
+type Arc struct {
+	Center, U, V Vec2
+	Start, Sweep float64
+}
+
+func (a *Arc) point(t float64) Vec2 {
+	ct, st := math.Cos(t), math.Sin(t)
+	return Vec2{a.Center[0] + a.U[0]*ct + a.V[0]*st, a.Center[1] + a.U[1]*ct + a.V[1]*st}
+}
+
+// transform returns the arc transformed by the affine map f.
+func (a *Arc) transform(f func(Vec2) Vec2) Arc {
+	c := f(a.Center)
+	lin := func(d Vec2) Vec2 {
+		v := f(a.Center.Add(d))
+		return Vec2{v[0] - c[0], v[1] - c[1]}
+	}
+	return Arc{Center: c, U: lin(a.U), V: lin(a.V), Start: a.Start, Sweep: a.Sweep}
+}
+
+// axes returns the radii of the ellipse, largest first, and the
+// angle of its major axis.
+func (a *Arc) axes() (rx, ry, phi float64) {
+	// This is the singular value decomposition of the matrix
+	// with columns U and V.
+	e, f := (a.U[0]+a.V[1])/2, (a.U[0]-a.V[1])/2
+	g, h := (a.U[1]+a.V[0])/2, (a.U[1]-a.V[0])/2
+	q, r := math.Hypot(e, h), math.Hypot(f, g)
+	return q + r, math.Abs(q - r), (math.Atan2(h, e) + math.Atan2(g, f)) / 2
+}
+
+// ccw reports whether the arc goes anticlockwise, in coordinates
+// where y points up.
+func (a *Arc) ccw() bool {
+	det := a.U[0]*a.V[1] - a.U[1]*a.V[0]
+	return (det > 0) == (a.Sweep > 0)
+}
+
+// Circle reports whether the arc is within tol of an arc of
+// a circle, and if so, returns the circle's radius and whether
+// the arc goes anticlockwise (in coordinates where y points up).
+func (a *Arc) Circle(tol float64) (r float64, ccw bool, ok bool) {
+	rx, ry, _ := a.axes()
+	if rx-ry > tol {
+		return 0, false, false
+	}
+	return (rx + ry) / 2, a.ccw(), true
+}
+
+// flatten approximates the arc by line segments, and appends the
+// vertices after its start to vs. No point on the arc is further
+// than tol from the segments.
+func (a *Arc) flatten(vs []Vec2, tol float64) []Vec2 {
+	// The second derivative of the arc is never longer than the
+	// ellipse's largest radius r. A step of h in t then strays at
+	// most h^2 r / 8 from the chord.
+	r, _, _ := a.axes()
+	h := math.Pi / 2
+	if r > 0 {
+		h = math.Min(h, math.Sqrt(8*tol/r))
+	}
+	h = math.Max(h, math.Pi/2/(1<<maxCurveDepth))
+	// The arc is broken at each quarter turn of t, so that the ends
+	// of the semi-diameters U and V (the extremes of an
+	// untransformed ellipse) are always vertices.
+	// The quarter turns are counted, rather than found from t, which
+	// may already be a quarter turn give or take rounding.
+	t, end := a.Start, a.Start+a.Sweep
+	dir := 1.0
+	if a.Sweep < 0 {
+		dir = -1
+	}
+	q := math.Floor(dir * t / (math.Pi / 2))
+	for dir*(end-t) > 0 {
+		q++
+		next := q * (math.Pi / 2) * dir
+		if dir*(next-end) > 0 || math.Abs(end-next) < 1e-9 {
+			next = end
+		} else if math.Abs(next-t) < 1e-9 {
+			continue
+		}
+		n := math.Ceil(math.Abs(next-t) / h)
+		for i := 1.0; i <= n; i++ {
+			vs = append(vs, a.point(t+(next-t)*i/n))
+		}
+		t = next
+	}
+	return vs
+}
+
+// arcSegment returns the segment for an SVG elliptical arc (see
+// svgArc) from p0 to p1, transformed by xf. If the radii are zero,
+// it's a straight line.
+func arcSegment(xf *Matrix, p0 Vec2, rx, ry, phi float64, large, sweep bool, p1 Vec2) Segment {
+	a, ok := svgArc(p0, rx, ry, phi, large, sweep, p1)
+	if !ok {
+		return Segment{}
+	}
+	return Segment{Kind: ArcSegment, Arc: a.transform(xf.Apply)}
+}
+
+// quadToCubic returns the control points of the cubic bezier
+// that's identical to the quadratic bezier with control points
+// p0, p1, p2.
+func quadToCubic(p0, p1, p2 Vec2) (Vec2, Vec2, Vec2, Vec2) {
+	return p0, p0.Lerp(p1, 2.0/3), p2.Lerp(p1, 2.0/3), p2
+}
+
+// vec2reflect returns the reflection of the point p about c.
+func vec2reflect(p, c Vec2) Vec2 {
+	return Vec2{2*c[0] - p[0], 2*c[1] - p[1]}
+}
+
+// vec2angle returns the signed angle from u to v.
+func vec2angle(u, v Vec2) float64 {
+	return math.Atan2(u[0]*v[1]-u[1]*v[0], u[0]*v[0]+u[1]*v[1])
+}
+
+// svgArc converts an SVG elliptical arc in endpoint
+// parameterization to an Arc. The arc goes from p0 to p1,
+// on an ellipse with radii rx, ry whose x-axis is rotated by phi
+// (in radians). The flags choose which of the four possible arcs
+// is used. It returns false if the radii are zero, in which case
+// the arc is a straight line. Conversion follows the
+// implementation notes in the SVG specification.
+func svgArc(p0 Vec2, rx, ry, phi float64, large, sweep bool, p1 Vec2) (Arc, bool) {
+	rx, ry = math.Abs(rx), math.Abs(ry)
+	if rx == 0 || ry == 0 {
+		return Arc{}, false
+	}
+	cphi, sphi := math.Cos(phi), math.Sin(phi)
+	dx, dy := (p0[0]-p1[0])/2, (p0[1]-p1[1])/2
+	x1 := cphi*dx + sphi*dy
+	y1 := -sphi*dx + cphi*dy
+
+	// Scale up the radii if they're too small for the arc to
+	// reach between the two points.
+	if l := x1*x1/(rx*rx) + y1*y1/(ry*ry); l > 1 {
+		rx *= math.Sqrt(l)
+		ry *= math.Sqrt(l)
+	}
+
+	num := rx*rx*ry*ry - rx*rx*y1*y1 - ry*ry*x1*x1
+	den := rx*rx*y1*y1 + ry*ry*x1*x1
+	k := math.Sqrt(math.Max(0, num/den))
+	if large == sweep {
+		k = -k
+	}
+	cx1 := k * rx * y1 / ry
+	cy1 := -k * ry * x1 / rx
+	c := Vec2{
+		cphi*cx1 - sphi*cy1 + (p0[0]+p1[0])/2,
+		sphi*cx1 + cphi*cy1 + (p0[1]+p1[1])/2,
+	}
+
+	theta1 := vec2angle(Vec2{1, 0}, Vec2{(x1 - cx1) / rx, (y1 - cy1) / ry})
+	dtheta := vec2angle(Vec2{(x1 - cx1) / rx, (y1 - cy1) / ry}, Vec2{(-x1 - cx1) / rx, (-y1 - cy1) / ry})
+	if !sweep && dtheta > 0 {
+		dtheta -= 2 * math.Pi
+	} else if sweep && dtheta < 0 {
+		dtheta += 2 * math.Pi
+	}
+	return Arc{
+		Center: c,
+		U:      Vec2{rx * cphi, rx * sphi},
+		V:      Vec2{-ry * sphi, ry * cphi},
+		Start:  theta1,
+		Sweep:  dtheta,
+	}, true
+}
@@ -0,0 +1,122 @@
+package paths
+
+import (
+	"math"
+	"testing"
+)
+
+// flatError returns the largest distance from the points of the
+// curve f (sampled finely for t in [0, 1]) to the polyline vs.
+func flatError(f func(t float64) Vec2, vs []Vec2) float64 {
+	worst := 0.0
+	for i := 0; i <= 2000; i++ {
+		p := f(float64(i) / 2000)
+		best := math.Inf(1)
+		for j := 1; j < len(vs); j++ {
+			best = math.Min(best, segmentDist(p, vs[j-1], vs[j]))
+		}
+		worst = math.Max(worst, best)
+	}
+	return worst
+}
+
+func cubicPoint(p0, p1, p2, p3 Vec2) func(t float64) Vec2 {
+	return func(t float64) Vec2 {
+		a, b, c := p0.Lerp(p1, t), p1.Lerp(p2, t), p2.Lerp(p3, t)
+		return a.Lerp(b, t).Lerp(b.Lerp(c, t), t)
+	}
+}
+
+func TestFlattenCubic(t *testing.T) {
+	cases := []struct {
+		desc           string
+		p0, p1, p2, p3 Vec2
+	}{
+		{"gentle", Vec2{0, 0}, Vec2{10, 2}, Vec2{20, 2}, Vec2{30, 0}},
+		{"s-bend", Vec2{0, 0}, Vec2{30, 0}, Vec2{0, 30}, Vec2{30, 30}},
+		{"cusp", Vec2{0, 0}, Vec2{40, 20}, Vec2{0, 20}, Vec2{40, 0}},
+		{"loop", Vec2{0, 0}, Vec2{60, 40}, Vec2{-20, 40}, Vec2{40, 0}},
+		{"straight", Vec2{0, 0}, Vec2{1, 1}, Vec2{2, 2}, Vec2{3, 3}},
+	}
+	for _, c := range cases {
+		var last int
+		for _, tol := range []float64{1, 0.1, 0.01} {
+			vs := flattenCubic([]Vec2{c.p0}, tol, c.p0, c.p1, c.p2, c.p3)
+			if vs[len(vs)-1] != c.p3 {
+				t.Errorf("%s: flattened curve ends at %v, want %v", c.desc, vs[len(vs)-1], c.p3)
+			}
+			if got := flatError(cubicPoint(c.p0, c.p1, c.p2, c.p3), vs); got > tol {
+				t.Errorf("%s: flattened with tolerance %g, curve is %g from its segments", c.desc, tol, got)
+			}
+			if len(vs) < last {
+				t.Errorf("%s: tolerance %g gave %d vertices, fewer than %d for a larger tolerance", c.desc, tol, len(vs), last)
+			}
+			last = len(vs)
+		}
+	}
+}
+
+func TestFlattenArc(t *testing.T) {
+	cases := []struct {
+		desc string
+		ea   Arc
+	}{
+		{"circle", Arc{Center: Vec2{0, 0}, U: Vec2{10, 0}, V: Vec2{0, 10}, Start: 0, Sweep: 2 * math.Pi}},
+		{"backwards", Arc{Center: Vec2{5, 5}, U: Vec2{10, 0}, V: Vec2{0, 10}, Start: 1, Sweep: -2.5}},
+		{"thin ellipse", Arc{Center: Vec2{0, 0}, U: Vec2{50, 0}, V: Vec2{0, 2}, Start: 0.3, Sweep: 4}},
+		{"sheared", Arc{Center: Vec2{0, 0}, U: Vec2{20, 5}, V: Vec2{15, 10}, Start: -1, Sweep: 3}},
+	}
+	for _, c := range cases {
+		f := func(t float64) Vec2 { return c.ea.point(c.ea.Start + c.ea.Sweep*t) }
+		for _, tol := range []float64{1, 0.1, 0.01} {
+			vs := c.ea.flatten([]Vec2{f(0)}, tol)
+			if got := flatError(f, vs); got > tol {
+				t.Errorf("%s: flattened with tolerance %g, arc is %g from its segments", c.desc, tol, got)
+			}
+		}
+	}
+}
+
+// TestFlattenArcQuarters checks arcs that start on a quarter turn,
+// which may be a little either side of it after rounding.
+func TestFlattenArcQuarters(t *testing.T) {
+	for _, k := range []int{0, 1, 4, 11, 15, 22, 30, -13, -26} {
+		for _, sweep := range []float64{math.Pi / 4, -math.Pi / 4, math.Pi / 2, 3 * math.Pi} {
+			p := CircleArc(Vec2{}, 10, float64(k)*math.Pi/2, sweep)
+			p.Flatten(0.01)
+			end := Vec2{10 * math.Cos(float64(k)*math.Pi/2+sweep), 10 * math.Sin(float64(k)*math.Pi/2+sweep)}
+			if len(p.V) < 2 || p.V[len(p.V)-1].Dist(end) > 1e-9 {
+				t.Errorf("arc from quarter turn %d sweeping %g flattened to %d vertices, ending at %v, want it to end at %v", k, sweep, len(p.V), p.V[len(p.V)-1], end)
+			}
+			for i := 1; i < len(p.V); i++ {
+				if p.V[i].Dist(p.V[i-1]) < 1e-6 {
+					t.Errorf("arc from quarter turn %d sweeping %g has repeated vertex %v", k, sweep, p.V[i])
+				}
+			}
+		}
+	}
+}
+
+// TestFlattenScale checks that the number of segments used for
+// a curve grows with the square root of its size, since the
+// distance between an arc and its chord grows with the square of
+// the chord's length.
+func TestFlattenScale(t *testing.T) {
+	cubic := func(scale float64) int {
+		s := func(x, y float64) Vec2 { return Vec2{x * scale, y * scale} }
+		return len(flattenCubic(nil, 0.05, s(0, 0), s(1, 0), s(0, 1), s(1, 1)))
+	}
+	circle := func(scale float64) int {
+		ea := Arc{U: Vec2{scale, 0}, V: Vec2{0, scale}, Sweep: 2 * math.Pi}
+		return len(ea.flatten(nil, 0.05))
+	}
+	for _, c := range []struct {
+		desc  string
+		count func(scale float64) int
+	}{{"cubic", cubic}, {"circle", circle}} {
+		small, big := c.count(10), c.count(1000)
+		if ratio := float64(big) / float64(small); ratio < 5 || ratio > 20 {
+			t.Errorf("%s: 10mm drawn with %d segments and 1000mm with %d, want about 10 times as many", c.desc, small, big)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package paths
+
+// complementRegion contains the points that aren't in its region.
+type complementRegion struct {
+	r region
+}
+
+func (cr complementRegion) contains(v Vec2) bool {
+	return !cr.r.contains(v)
+}
+
+func (cr complementRegion) edges(es [][2]Vec2) [][2]Vec2 {
+	return cr.r.edges(es)
+}
+
+// marginArea returns the region of points that are inside r, or
+// within distance d of its boundary. The corners of the margin are
+// flattened, so it's at least d wide, and no more than
+// curveTolerance wider.
+func marginArea(r region, d float64) region {
+	if d <= 0 {
+		return r
+	}
+	ur := unionRegion{r}
+	for _, s := range stadiums(r.edges(nil), d) {
+		ur = append(ur, &polygonRegion{rings: [][]Vec2{s.ring}})
+	}
+	return ur
+}
+
+// Erase removes all parts of the paths inside the area enclosed by
+// the polygon, or within margin of its edge, leaving a gap where
+// something else can go. The polygon is made of one or more paths,
+// which are treated as in ClipToPolygon. The margin is measured to
+// within 0.05. As with Clip, paths that cross into the erased area
+// are broken into multiple paths.
+func (ps *Paths) Erase(poly []Path, rule FillRule, margin float64) {
+	ps.P = clipToRegion(ps.P, complementRegion{marginArea(polygonArea(poly, rule), margin)})
+}
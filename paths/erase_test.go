@@ -0,0 +1,81 @@
+package paths
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestErase(t *testing.T) {
+	// Where the line y=6.5 is 2 from the corners of the square.
+	x := 5 + math.Sqrt(4-1.5*1.5)
+	cases := []struct {
+		desc   string
+		poly   []Path
+		rule   FillRule
+		margin float64
+		path   Path
+		want   []Path
+		tol    float64
+	}{
+		{"square", []Path{rect(-5, -5, 5, 5)}, NonZero, 0, line(-20, 0, 20, 0), []Path{line(-20, 0, -5, 0), line(5, 0, 20, 0)}, 1e-9},
+		{"square with margin", []Path{rect(-5, -5, 5, 5)}, NonZero, 2, line(-20, 0, 20, 0), []Path{line(-20, 0, -7, 0), line(7, 0, 20, 0)}, curveTolerance},
+		{"margin around corners", []Path{rect(-5, -5, 5, 5)}, NonZero, 2, line(-20, 6.5, 20, 6.5), []Path{line(-20, 6.5, -x, 6.5), line(x, 6.5, 20, 6.5)}, curveTolerance},
+		{"clear of margin", []Path{rect(-5, -5, 5, 5)}, NonZero, 2, line(-20, 8, 20, 8), []Path{line(-20, 8, 20, 8)}, 0},
+		{
+			desc: "two squares",
+			poly: []Path{rect(-15, -5, -10, 5), rect(10, -5, 15, 5)},
+			path: line(-20, 0, 20, 0),
+			want: []Path{line(-20, 0, -15, 0), line(-10, 0, 10, 0), line(15, 0, 20, 0)},
+			tol:  1e-9,
+		},
+		{
+			desc: "square with a hole",
+			poly: []Path{rect(-10, -10, 10, 10), rect(-5, -5, 5, 5)},
+			rule: EvenOdd,
+			path: line(-20, 0, 20, 0),
+			want: []Path{line(-20, 0, -10, 0), line(-5, 0, 5, 0), line(10, 0, 20, 0)},
+			tol:  1e-9,
+		},
+		{"circle", []Path{Circle(Vec2{}, 5)}, NonZero, 1, line(-20, 0, 20, 0), []Path{line(-20, 0, -6, 0), line(6, 0, 20, 0)}, curveTolerance},
+	}
+	for _, c := range cases {
+		ps := &Paths{P: []Path{c.path.clone()}}
+		ps.Erase(c.poly, c.rule, c.margin)
+		if !shapesNear(ps.P, c.want, c.tol) {
+			t.Errorf("%s: got %v, want %v", c.desc, ps.P, c.want)
+		}
+	}
+
+	// The margin is never less than asked for.
+	grid := &Paths{}
+	for y := -10.0; y <= 10; y += 0.25 {
+		grid.P = append(grid.P, line(-10, y, 10, y), line(y, -10, y, 10))
+	}
+	poly := []Path{Star(Vec2{}, 5, 5, 2, 0.3)}
+	grid.Erase(poly, NonZero, 1.5)
+	edges := polygonArea(poly, NonZero).edges(nil)
+	for _, p := range grid.P {
+		for i := 1; i < len(p.V); i++ {
+			for _, e := range edges {
+				// The segments don't cross, so they're closest at
+				// one of their ends.
+				d := math.Min(segmentDist(p.V[i-1], e[0], e[1]), segmentDist(p.V[i], e[0], e[1]))
+				d = math.Min(d, math.Min(segmentDist(e[0], p.V[i-1], p.V[i]), segmentDist(e[1], p.V[i-1], p.V[i])))
+				if d < 1.5-1e-9 {
+					t.Errorf("line %v is only %g from the edge %v", p.V[i-1:i+1], d, e)
+				}
+			}
+		}
+	}
+
+	// Closed paths clear of the polygon stay closed, and attributes
+	// are kept.
+	out := rect(20, 20, 30, 30)
+	out.Attrs = Attrs{Layer: "outside", Color: "#ff0000"}
+	ps := &Paths{P: []Path{out.clone()}}
+	ps.Erase([]Path{rect(-5, -5, 5, 5)}, NonZero, 1)
+	if !reflect.DeepEqual(ps.P, []Path{out}) {
+		t.Errorf("closed path clear of polygon erased to %v, want %v", ps.P, []Path{out})
+	}
+}
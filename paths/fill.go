@@ -0,0 +1,327 @@
+package paths
+
+import (
+	"math"
+	"sort"
+)
+
+// A FillPattern is a way of filling an area with lines.
+type FillPattern int
+
+const (
+	// HatchFill fills an area with parallel straight lines.
+	HatchFill FillPattern = iota
+	// ConcentricFill fills an area with rings, each inside the last.
+	ConcentricFill
+	// SpiralFill is like ConcentricFill, but joins the rings up into
+	// spirals, so that an area that doesn't split into pieces as it's
+	// filled is drawn with a single line.
+	SpiralFill
+	// ZigzagFill fills an area with rows of zigzags.
+	ZigzagFill
+	// WaveFill fills an area with rows of waves.
+	WaveFill
+)
+
+// concentricRings returns the rings that fill the region r, spacing
+// apart. The first rings are half the spacing in from the edge of
+// the region, and each level of rings is inside the last.
+func concentricRings(r region, spacing float64) [][]Path {
+	es := r.edges(nil)
+	loops := insetLoops(r, spacing/2)
+	var levels [][]Path
+	for k := 1; len(loops) > 0; k++ {
+		levels = append(levels, loops)
+		// Each level is inset from the last, so that only nearby
+		// edges are compared. Flattening curved rings leaves them
+		// a little out, and so that doesn't add up from one level
+		// to the next, their vertices are moved to the right
+		// distance from the edges of r.
+		pr := &polygonRegion{}
+		for _, l := range loops {
+			pr.rings = append(pr.rings, l.V[:len(l.V)-1])
+		}
+		d := (float64(k) + 0.5) * spacing
+		loops = loops[:0:0]
+		for _, l := range offsetArea(pr, -spacing, RoundJoin) {
+			vs := simplifyLine(l.V, true)
+			if len(vs) < 3 {
+				continue
+			}
+			for i, v := range vs {
+				vs[i] = towardEdges(v, es, d)
+			}
+			loops = append(loops, Path{V: append(vs, vs[0]), Closed: true})
+		}
+	}
+	return levels
+}
+
+// nearestOnEdges returns the point of the edges es that's nearest
+// to v, and its distance from v.
+func nearestOnEdges(v Vec2, es [][2]Vec2) (Vec2, float64) {
+	var q Vec2
+	best := math.Inf(1)
+	for _, e := range es {
+		if w := nearestOnSegment(v, e[0], e[1]); v.Dist(w) < best {
+			q, best = w, v.Dist(w)
+		}
+	}
+	return q, best
+}
+
+// towardEdges returns v moved so that it's d from the edges es. It's
+// moved straight toward or away from the nearest point of the edges,
+// unless that would bring it nearer than d to another part of them,
+// in which case it's moved to where it's d from both.
+func towardEdges(v Vec2, es [][2]Vec2, d float64) Vec2 {
+	q1, d1 := nearestOnEdges(v, es)
+	if d1 == 0 {
+		return v
+	}
+	w := q1.Lerp(v, d/d1)
+	q2, d2 := nearestOnEdges(w, es)
+	if d2 >= d-1e-9 {
+		return w
+	}
+	// The distances from the two nearest points change by g1 and
+	// g2 as v moves, so solve g1.m = d - d1 and g2.m = d - |v-q2|.
+	g1 := v.Sub(q1).Scale(1 / d1)
+	g2 := v.Sub(q2)
+	l2 := g2.Len()
+	if l2 == 0 {
+		return w
+	}
+	g2 = g2.Scale(1 / l2)
+	det := g1[0]*g2[1] - g1[1]*g2[0]
+	if math.Abs(det) < 1e-6 {
+		return w
+	}
+	a, b := d-d1, d-l2
+	return v.Add(Vec2{(a*g2[1] - b*g1[1]) / det, (b*g1[0] - a*g2[0]) / det})
+}
+
+// loopDist returns the distance from v to the path p.
+func loopDist(v Vec2, p *Path) float64 {
+	d := math.Inf(1)
+	for i := 1; i < len(p.V); i++ {
+		d = math.Min(d, segmentDist(v, p.V[i-1], p.V[i]))
+	}
+	return d
+}
+
+// startLoopAt returns the loop p, starting from the point on it
+// that's nearest to v.
+func startLoopAt(p *Path, v Vec2) []Vec2 {
+	best, bestD, bestT := 0, math.Inf(1), 0.0
+	for i := 1; i < len(p.V); i++ {
+		a, b := p.V[i-1], p.V[i]
+		d := b.Sub(a)
+		t := 0.0
+		if dd := d[0]*d[0] + d[1]*d[1]; dd > 0 {
+			t = math.Max(0, math.Min(1, ((v[0]-a[0])*d[0]+(v[1]-a[1])*d[1])/dd))
+		}
+		if dist := v.Dist(a.Lerp(b, t)); dist < bestD {
+			best, bestD, bestT = i, dist, t
+		}
+	}
+	start := p.V[best-1].Lerp(p.V[best], bestT)
+	vs := []Vec2{start}
+	vs = append(vs, p.V[best:]...)
+	vs = append(vs, p.V[1:best]...)
+	return append(vs, start)
+}
+
+// arcLengths returns the distance along vs to each of its vertices,
+// as a fraction of its length.
+func arcLengths(vs []Vec2) []float64 {
+	ls := make([]float64, len(vs))
+	for i := 1; i < len(vs); i++ {
+		ls[i] = ls[i-1] + vs[i].Dist(vs[i-1])
+	}
+	if total := ls[len(ls)-1]; total > 0 {
+		for i := range ls {
+			ls[i] /= total
+		}
+	}
+	return ls
+}
+
+// pointAlong returns the point that's fraction t of the way along
+// vs, whose vertices are at fractions ls.
+func pointAlong(vs []Vec2, ls []float64, t float64) Vec2 {
+	i := sort.SearchFloat64s(ls, t)
+	if i == 0 {
+		return vs[0]
+	}
+	if i == len(vs) {
+		return vs[len(vs)-1]
+	}
+	if ls[i] == ls[i-1] {
+		return vs[i]
+	}
+	return vs[i-1].Lerp(vs[i], (t-ls[i-1])/(ls[i]-ls[i-1]))
+}
+
+// ringSpiral joins up the loops of rings, each inside the last and
+// going the same way round, into a spiral. The first loop is drawn
+// in full, then each turn of the spiral goes round one loop, moving
+// steadily across to the next, and the last loop is drawn in full
+// too.
+func ringSpiral(rings []Path) Path {
+	if len(rings) == 1 {
+		return rings[0]
+	}
+	a := rings[0].V
+	vs := append([]Vec2{}, a[:len(a)-1]...)
+	for i := 1; i < len(rings); i++ {
+		b := startLoopAt(&rings[i], a[0])
+		la, lb := arcLengths(a), arcLengths(b)
+		ts := append(append([]float64{}, la...), lb...)
+		sort.Float64s(ts)
+		for _, t := range ts {
+			v := pointAlong(a, la, t).Lerp(pointAlong(b, lb, t), t)
+			if len(vs) == 0 || vs[len(vs)-1] != v {
+				vs = append(vs, v)
+			}
+		}
+		a = b
+	}
+	for _, v := range a {
+		if len(vs) == 0 || vs[len(vs)-1] != v {
+			vs = append(vs, v)
+		}
+	}
+	return Path{V: vs}
+}
+
+// spirals joins the levels of rings from concentricRings into
+// spirals. Rings are joined to the single ring inside them, and
+// where a ring has more than one ring inside it, or none, a spiral
+// ends.
+func spirals(levels [][]Path) []Path {
+	// parent[k][i] is the index of the ring that the i'th ring of
+	// level k is inside, and children counts the rings inside each.
+	parent := make([][]int, len(levels))
+	children := make([][]int, len(levels))
+	for k, loops := range levels {
+		parent[k] = make([]int, len(loops))
+		children[k] = make([]int, len(loops))
+		for i := range loops {
+			parent[k][i] = -1
+			if k == 0 || !loops[i].Closed {
+				continue
+			}
+			best := math.Inf(1)
+			for j := range levels[k-1] {
+				if d := loopDist(loops[i].V[0], &levels[k-1][j]); d < best && levels[k-1][j].Closed {
+					parent[k][i], best = j, d
+				}
+			}
+			if parent[k][i] >= 0 {
+				children[k-1][parent[k][i]]++
+			}
+		}
+	}
+	// joined reports whether the i'th ring of level k carries on the
+	// spiral of the ring it's inside.
+	joined := func(k, i int) bool {
+		return k > 0 && parent[k][i] >= 0 && children[k-1][parent[k][i]] == 1
+	}
+	var ps []Path
+	for k, loops := range levels {
+		for i := range loops {
+			if joined(k, i) {
+				continue
+			}
+			rings := []Path{loops[i]}
+			for kk, ii := k, i; kk+1 < len(levels) && children[kk][ii] == 1; kk++ {
+				for j := range levels[kk+1] {
+					if parent[kk+1][j] == ii {
+						ii = j
+						break
+					}
+				}
+				rings = append(rings, levels[kk+1][ii])
+			}
+			if !loops[i].Closed {
+				ps = append(ps, loops[i])
+				continue
+			}
+			ps = append(ps, ringSpiral(rings))
+		}
+	}
+	return ps
+}
+
+// periodicRow returns a hatchRow of the curve y + f(x), which
+// repeats every period, sampled every step.
+func periodicRow(f func(x float64) float64, period, step float64) hatchRow {
+	n := int(math.Ceil(period / step))
+	return func(hr *polygonRegion, es [][2]Vec2, y, x0, x1 float64) [][]Vec2 {
+		var row Path
+		x := math.Floor(x0/period) * period
+		for ; ; x += period {
+			for i := 0; i < n; i++ {
+				xi := x + period*float64(i)/float64(n)
+				row.V = append(row.V, Vec2{xi, y + f(xi)})
+			}
+			if x > x1 {
+				break
+			}
+		}
+		var pieces [][]Vec2
+		for _, p := range clipToRegion([]Path{row}, hr) {
+			pieces = append(pieces, p.V)
+		}
+		return pieces
+	}
+}
+
+// Fill adds lines that fill the area enclosed by the polygon, which
+// is made of one or more paths as in ClipToPolygon, with a pattern.
+// Rows of hatching, zigzags and waves are spacing apart, at angle
+// theta (in radians) from the x axis, and they're joined up where
+// they can be, as with Hatch. Zigzags and waves are spacing high,
+// and repeat every four times the spacing. Concentric rings are
+// spacing apart, starting half the spacing in from the edge, and
+// theta isn't used for them. The lines have the attributes of the
+// first path of the polygon, and nothing is added if spacing isn't
+// positive.
+func (ps *Paths) Fill(poly []Path, rule FillRule, pattern FillPattern, theta, spacing float64) {
+	if spacing <= 0 || len(poly) == 0 {
+		return
+	}
+	pr := polygonArea(poly, rule)
+	period := 4 * spacing
+	var lines []Path
+	switch pattern {
+	case HatchFill:
+		lines = hatchRows(pr, theta, spacing, true, straightRow)
+	case ConcentricFill:
+		for _, loops := range concentricRings(pr, spacing) {
+			lines = append(lines, loops...)
+		}
+	case SpiralFill:
+		lines = spirals(concentricRings(pr, spacing))
+	case ZigzagFill:
+		zigzag := func(x float64) float64 {
+			return spacing * (math.Abs(math.Mod(math.Abs(x)/period*2, 2)-1) - 0.5)
+		}
+		lines = hatchRows(pr, theta, spacing, true, periodicRow(zigzag, period, period/2))
+	case WaveFill:
+		wave := func(x float64) float64 {
+			return spacing / 2 * math.Sin(2*math.Pi*x/period)
+		}
+		// The curvature of the wave is at most pi^2/(8 spacing),
+		// and a line between points h apart on a curve of
+		// curvature k is about kh^2/8 from it, so with 32 points
+		// to a wave, the lines stay within spacing/400 of it,
+		// whatever units the spacing is in.
+		lines = hatchRows(pr, theta, spacing, true, periodicRow(wave, period, period/32))
+	}
+	for i := range lines {
+		lines[i].Attrs = poly[0].Attrs
+	}
+	ps.P = append(ps.P, lines...)
+}
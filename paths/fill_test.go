@@ -0,0 +1,153 @@
+package paths
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+// edgeDist returns the distance from v to the nearest of the edges es.
+func edgeDist(v Vec2, es [][2]Vec2) float64 {
+	d := math.Inf(1)
+	for _, e := range es {
+		d = math.Min(d, segmentDist(v, e[0], e[1]))
+	}
+	return d
+}
+
+func TestConcentricRings(t *testing.T) {
+	square := RoundedRect(Bounds{Max: Vec2{10, 10}}, 0, 0)
+	levels := concentricRings(polygonArea([]Path{square}, NonZero), 1)
+	if len(levels) != 5 {
+		t.Fatalf("square has %d levels of rings, want 5", len(levels))
+	}
+	for k, loops := range levels {
+		side := 9 - 2*float64(k)
+		if len(loops) != 1 || !loops[0].Closed || math.Abs(loopArea(loops[0].V)-side*side) > 1e-6 {
+			t.Errorf("square's rings at level %d are %v, want one anticlockwise square of side %g", k, loops, side)
+		}
+	}
+
+	hole := RoundedRect(Bounds{Min: Vec2{3, 3}, Max: Vec2{7, 7}}, 0, 0)
+	cases := []struct {
+		desc string
+		poly []Path
+		rule FillRule
+	}{
+		{"square", []Path{square}, NonZero},
+		{"circle", []Path{Circle(Vec2{3, 4}, 5)}, NonZero},
+		{"star", []Path{Star(Vec2{}, 7, 10, 3, 0.1)}, NonZero},
+		{"hole", []Path{square, hole}, EvenOdd},
+		// Many levels of rings, with curves around the corners of
+		// the hole that are flattened at each level.
+		{"big hole", []Path{rect(0, 0, 40, 40), rect(15, 15, 25, 25)}, EvenOdd},
+	}
+	for _, c := range cases {
+		area := polygonArea(c.poly, c.rule)
+		es := area.edges(nil)
+		for k, loops := range concentricRings(area, 0.7) {
+			d := (float64(k) + 0.5) * 0.7
+			for _, p := range loops {
+				if !p.Closed {
+					t.Errorf("%s: ring %v at level %d isn't closed", c.desc, p.V, k)
+				}
+				for _, v := range p.V {
+					if got := edgeDist(v, es); got < d-1e-9 || got > d+curveTolerance+1e-9 || !area.contains(v) {
+						t.Errorf("%s: ring at level %d has %v, %g from the edge, want %g", c.desc, k, v, got, d)
+					}
+				}
+			}
+		}
+	}
+
+	if got := concentricRings(polygonArea([]Path{square}, NonZero), 11); len(got) != 0 {
+		t.Errorf("square with wide spacing has rings %v, want none", got)
+	}
+}
+
+func TestFill(t *testing.T) {
+	cases := []struct {
+		desc   string
+		poly   []Path
+		rule   FillRule
+		convex bool
+	}{
+		{"square", []Path{rect(0, 0, 10, 10)}, NonZero, true},
+		{"circle", []Path{Circle(Vec2{3, 4}, 5)}, NonZero, true},
+		{"star", []Path{Star(Vec2{}, 7, 10, 3, 0.1)}, NonZero, false},
+		{"hole", []Path{rect(0, 0, 10, 10), rect(3, 3, 7, 7)}, EvenOdd, false},
+	}
+	const spacing = 0.5
+	for _, c := range cases {
+		c.poly[0].Attrs = Attrs{Pen: 3}
+		area := polygonArea(c.poly, c.rule)
+		es := area.edges(nil)
+		for _, pattern := range []FillPattern{HatchFill, ConcentricFill, SpiralFill, ZigzagFill, WaveFill} {
+			for _, theta := range []float64{0, 1} {
+				desc := fmt.Sprintf("%s with pattern %d at %g", c.desc, pattern, theta)
+				ps := &Paths{}
+				ps.Fill(c.poly, c.rule, pattern, theta, spacing)
+				if len(ps.P) == 0 {
+					t.Errorf("%s: nothing was drawn", desc)
+					continue
+				}
+				for _, p := range ps.P {
+					if p.Attrs != c.poly[0].Attrs {
+						t.Errorf("%s: path has attributes %+v, want %+v", desc, p.Attrs, c.poly[0].Attrs)
+					}
+					for i := 1; i < len(p.V); i++ {
+						if m := p.V[i-1].Lerp(p.V[i], 0.5); !area.contains(m) && !onEdge(m, es) {
+							t.Errorf("%s: line %v goes outside", desc, p.V[i-1:i+1])
+						}
+					}
+				}
+				if pattern == SpiralFill && c.convex && len(ps.P) != 1 {
+					t.Errorf("%s: drawn with %d paths, want 1", desc, len(ps.P))
+				}
+				if pattern != ConcentricFill && c.convex && len(ps.P) > 3 {
+					t.Errorf("%s: drawn with %d paths, want them joined up", desc, len(ps.P))
+				}
+				// Every point of the area is near a line, except in
+				// corners too sharp for the rings to reach into.
+				var segs [][2]Vec2
+				for _, p := range ps.P {
+					for i := 1; i < len(p.V); i++ {
+						segs = append(segs, [2]Vec2{p.V[i-1], p.V[i]})
+					}
+				}
+				for x := -10.0; x <= 10; x += 0.37 {
+					for y := -10.0; y <= 10; y += 0.37 {
+						v := Vec2{x, y}
+						if area.contains(v) && edgeDist(v, es) >= spacing/2 && edgeDist(v, segs) > spacing {
+							t.Errorf("%s: %v is %g from the lines, want no more than %g", desc, v, edgeDist(v, segs), spacing)
+						}
+					}
+				}
+			}
+		}
+	}
+
+	ps := &Paths{}
+	ps.Fill([]Path{rect(0, 0, 10, 10)}, NonZero, SpiralFill, 0, 0)
+	if len(ps.P) != 0 {
+		t.Errorf("filling with no spacing gave %v, want nothing", ps.P)
+	}
+}
+
+func BenchmarkFill(b *testing.B) {
+	circle := Polygon(Vec2{}, 49, 20, 0)
+	for _, pattern := range []struct {
+		name    string
+		pattern FillPattern
+	}{
+		{"concentric", ConcentricFill},
+		{"spiral", SpiralFill},
+	} {
+		b.Run(pattern.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				ps := &Paths{}
+				ps.Fill([]Path{circle}, NonZero, pattern.pattern, 0, 1)
+			}
+		})
+	}
+}
@@ -206,14 +206,12 @@ func (f *Font) ScaleFromRuneHeight(r rune, height float64) (float64, error) {
 	return height / g.Height, nil
 }
 
-func (g *FontGlyph) TransformMatrixCopy(m *svgXform) []Path {
+func (g *FontGlyph) TransformMatrixCopy(m *Matrix) []Path {
 	ps := make([]Path, 0, len(g.Paths.P))
 	for _, p := range g.Paths.P {
-		pc := make([]Vec2, len(p.V))
-		for i, v := range p.V {
-			pc[i] = m.Apply(v)
-		}
-		ps = append(ps, Path{V: pc})
+		pc := p.clone()
+		pc.transform(m.Apply)
+		ps = append(ps, pc)
 	}
 	return ps
 }
@@ -221,7 +219,7 @@ func (g *FontGlyph) TransformMatrixCopy(m *svgXform) []Path {
 func GlyphsToPaths(offset Vec2, pgs []PositionedGlyph) *Paths {
 	ps := &Paths{}
 	for _, pg := range pgs {
-		m := svgXform{M: [3][3]float64{
+		m := Matrix{M: [3][3]float64{
 			{pg.Scale, 0, pg.Pos[0] + offset[0]},
 			{0, pg.Scale, pg.Pos[1] + offset[1]},
 			{0, 0, 1},
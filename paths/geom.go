@@ -0,0 +1,177 @@
+package paths
+
+import "math"
+
+// Add returns v + w.
+func (v Vec2) Add(w Vec2) Vec2 {
+	return Vec2{v[0] + w[0], v[1] + w[1]}
+}
+
+// Sub returns v - w.
+func (v Vec2) Sub(w Vec2) Vec2 {
+	return Vec2{v[0] - w[0], v[1] - w[1]}
+}
+
+// Scale returns v multiplied by s.
+func (v Vec2) Scale(s float64) Vec2 {
+	return Vec2{v[0] * s, v[1] * s}
+}
+
+// Len returns the length of v.
+func (v Vec2) Len() float64 {
+	return math.Hypot(v[0], v[1])
+}
+
+// Dist returns the distance between v and w.
+func (v Vec2) Dist(w Vec2) float64 {
+	return v.Sub(w).Len()
+}
+
+// Lerp returns the point that's a fraction s of the way from v to w.
+func (v Vec2) Lerp(w Vec2, s float64) Vec2 {
+	return Vec2{v[0]*(1-s) + w[0]*s, v[1]*(1-s) + w[1]*s}
+}
+
+// Empty reports whether the bounds contain no points.
+func (b Bounds) Empty() bool {
+	return b.Min[0] > b.Max[0] || b.Min[1] > b.Max[1]
+}
+
+// Size returns the width and height of the bounds.
+func (b Bounds) Size() Vec2 {
+	return b.Max.Sub(b.Min)
+}
+
+// Center returns the point at the center of the bounds.
+func (b Bounds) Center() Vec2 {
+	return b.Min.Lerp(b.Max, 0.5)
+}
+
+// Contains reports whether v is inside the bounds, or on their edge.
+func (b Bounds) Contains(v Vec2) bool {
+	return v[0] >= b.Min[0] && v[0] <= b.Max[0] && v[1] >= b.Min[1] && v[1] <= b.Max[1]
+}
+
+// Union returns the smallest bounds that contain both b and c.
+// Empty bounds are ignored.
+func (b Bounds) Union(c Bounds) Bounds {
+	if b.Empty() {
+		return c
+	}
+	if c.Empty() {
+		return b
+	}
+	return Bounds{
+		Min: Vec2{math.Min(b.Min[0], c.Min[0]), math.Min(b.Min[1], c.Min[1])},
+		Max: Vec2{math.Max(b.Max[0], c.Max[0]), math.Max(b.Max[1], c.Max[1])},
+	}
+}
+
+// Intersect returns the largest bounds contained in both b and c.
+// If they don't overlap, the result is Empty.
+func (b Bounds) Intersect(c Bounds) Bounds {
+	return Bounds{
+		Min: Vec2{math.Max(b.Min[0], c.Min[0]), math.Max(b.Min[1], c.Min[1])},
+		Max: Vec2{math.Min(b.Max[0], c.Max[0]), math.Min(b.Max[1], c.Max[1])},
+	}
+}
+
+// Expand returns the bounds grown by d on every side. If d is
+// negative, the bounds shrink, and may become Empty.
+func (b Bounds) Expand(d float64) Bounds {
+	return Bounds{
+		Min: Vec2{b.Min[0] - d, b.Min[1] - d},
+		Max: Vec2{b.Max[0] + d, b.Max[1] + d},
+	}
+}
+
+// segmentDist returns the distance from v to the line segment a-b.
+func segmentDist(v, a, b Vec2) float64 {
+	return v.Dist(nearestOnSegment(v, a, b))
+}
+
+// nearestOnSegment returns the point on the line segment from a to b
+// that's nearest to v.
+func nearestOnSegment(v, a, b Vec2) Vec2 {
+	d := Vec2{b[0] - a[0], b[1] - a[1]}
+	dd := d[0]*d[0] + d[1]*d[1]
+	if dd == 0 {
+		return a
+	}
+	t := ((v[0]-a[0])*d[0] + (v[1]-a[1])*d[1]) / dd
+	return a.Lerp(b, math.Max(0, math.Min(1, t)))
+}
+
+// A boxGrid finds the boxes that might meet a given box, by putting
+// each of them in the squares of a grid that it meets.
+type boxGrid struct {
+	size  float64
+	cells map[[2]int64][]int
+	seen  []int // when each box was last found by near
+	query int
+}
+
+func newBoxGrid(boxes []Bounds) *boxGrid {
+	// The squares start off the average size of the boxes, and grow
+	// until the boxes are in a few squares each, which they might
+	// not be if some are much bigger than the rest.
+	g := &boxGrid{cells: map[[2]int64][]int{}, seen: make([]int, len(boxes))}
+	for _, b := range boxes {
+		g.size += math.Max(b.Max[0]-b.Min[0], b.Max[1]-b.Min[1]) / float64(len(boxes))
+	}
+	if g.size <= 0 {
+		g.size = 1
+	}
+	for {
+		n := 0.0
+		for _, b := range boxes {
+			n += (math.Floor(b.Max[0]/g.size) - math.Floor(b.Min[0]/g.size) + 1) * (math.Floor(b.Max[1]/g.size) - math.Floor(b.Min[1]/g.size) + 1)
+		}
+		if n <= float64(8*len(boxes)) {
+			break
+		}
+		g.size *= 2
+	}
+	for i, b := range boxes {
+		g.each(b.Min, b.Max, func(k [2]int64) {
+			g.cells[k] = append(g.cells[k], i)
+		})
+	}
+	return g
+}
+
+// each calls f with each square of the grid that the box from min
+// to max meets.
+func (g *boxGrid) each(min, max Vec2, f func(k [2]int64)) {
+	x0, y0 := int64(math.Floor(min[0]/g.size)), int64(math.Floor(min[1]/g.size))
+	x1, y1 := int64(math.Floor(max[0]/g.size)), int64(math.Floor(max[1]/g.size))
+	for x := x0; x <= x1; x++ {
+		for y := y0; y <= y1; y++ {
+			f([2]int64{x, y})
+		}
+	}
+}
+
+// near appends to is the indexes of the boxes that might meet the
+// box from min to max, each once.
+func (g *boxGrid) near(is []int, min, max Vec2) []int {
+	g.query++
+	g.each(min, max, func(k [2]int64) {
+		for _, i := range g.cells[k] {
+			if g.seen[i] != g.query {
+				g.seen[i] = g.query
+				is = append(is, i)
+			}
+		}
+	})
+	return is
+}
+
+// segmentBounds returns the bounding box of the line segment from a
+// to b, grown by d on every side.
+func segmentBounds(a, b Vec2, d float64) Bounds {
+	return Bounds{
+		Min: Vec2{math.Min(a[0], b[0]) - d, math.Min(a[1], b[1]) - d},
+		Max: Vec2{math.Max(a[0], b[0]) + d, math.Max(a[1], b[1]) + d},
+	}
+}
@@ -0,0 +1,231 @@
+package paths
+
+import (
+	"math"
+	"sort"
+)
+
+// HatchConfig provides options for hatching.
+type HatchConfig struct {
+	Cross bool // also hatch with lines at right angles to the first
+	Link  bool // join the ends of adjacent lines to reduce pen lifts
+}
+
+// hatchCrossing is where a hatch line crosses an edge of a
+// polygon, and whether the edge goes up (1) or down (-1).
+type hatchCrossing struct {
+	x   float64
+	dir int
+}
+
+// hatchSpans appends to spans the parts of the horizontal line at
+// height y that are inside the polygon with edges es, from left to
+// right.
+func hatchSpans(spans [][2]float64, es [][2]Vec2, y float64, evenOdd bool) [][2]float64 {
+	var cs []hatchCrossing
+	for _, e := range es {
+		a, b := e[0], e[1]
+		if (a[1] <= y) == (b[1] <= y) {
+			continue
+		}
+		c := hatchCrossing{x: a[0] + (y-a[1])*(b[0]-a[0])/(b[1]-a[1]), dir: 1}
+		if b[1] < a[1] {
+			c.dir = -1
+		}
+		cs = append(cs, c)
+	}
+	sort.Slice(cs, func(i, j int) bool { return cs[i].x < cs[j].x })
+	inside := func(w int) bool {
+		if evenOdd {
+			return w%2 != 0
+		}
+		return w != 0
+	}
+	w := 0
+	var start float64
+	for _, c := range cs {
+		was := inside(w)
+		w += c.dir
+		if is := inside(w); is && !was {
+			start = c.x
+		} else if !is && was && c.x > start {
+			spans = append(spans, [2]float64{start, c.x})
+		}
+	}
+	return spans
+}
+
+// onEdge reports whether v is on one of the edges es.
+func onEdge(v Vec2, es [][2]Vec2) bool {
+	for _, e := range es {
+		if segmentDist(v, e[0], e[1]) < 1e-9 {
+			return true
+		}
+	}
+	return false
+}
+
+// crossesEdges reports whether the line from a to b crosses any of
+// the edges es. Touching an edge, or running along it, doesn't count.
+func crossesEdges(a, b Vec2, es [][2]Vec2) bool {
+	side := func(x, eps float64) int {
+		if x > eps {
+			return 1
+		} else if x < -eps {
+			return -1
+		}
+		return 0
+	}
+	for _, e := range es {
+		eps := 1e-9 * a.Dist(b) * e[0].Dist(e[1])
+		s0, s1 := side(cross(a, b, e[0]), eps), side(cross(a, b, e[1]), eps)
+		t0, t1 := side(cross(e[0], e[1], a), eps), side(cross(e[0], e[1], b), eps)
+		if s0*s1 < 0 && t0*t1 < 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// hatchChain is a sequence of hatch lines joined end to end.
+type hatchChain struct {
+	vs    []Vec2
+	right bool // whether the last line goes towards +x
+}
+
+// hatchRow returns the pieces of the row of hatching at height y
+// that are inside the polygon hr, whose edges are es, and which
+// lies between x0 and x1. The pieces each go from left to right,
+// and they're in order from left to right.
+type hatchRow func(hr *polygonRegion, es [][2]Vec2, y, x0, x1 float64) [][]Vec2
+
+// straightRow is a hatchRow of straight lines.
+func straightRow(hr *polygonRegion, es [][2]Vec2, y, x0, x1 float64) [][]Vec2 {
+	var pieces [][]Vec2
+	for _, s := range hatchSpans(nil, es, y, hr.evenOdd) {
+		pieces = append(pieces, []Vec2{{s[0], y}, {s[1], y}})
+	}
+	return pieces
+}
+
+// hatchRows returns rows of lines, spacing apart, at angle theta to
+// the x axis, that fill the polygon. In the hatching frame, where
+// the rows are horizontal, each is made by row. The rows are on a
+// grid that's the same for every polygon, so that neighbouring
+// shapes line up. If link is set, the end of each line is joined to
+// a line in the next row, going back the other way, wherever the
+// join stays inside the polygon and is no more than four times the
+// spacing.
+func hatchRows(pr *polygonRegion, theta, spacing float64, link bool, row hatchRow) []Path {
+	cos, sin := math.Cos(theta), math.Sin(theta)
+	// The rows are horizontal in the hatching frame.
+	to := func(v Vec2) Vec2 { return Vec2{v[0]*cos + v[1]*sin, v[1]*cos - v[0]*sin} }
+	from := func(v Vec2) Vec2 { return Vec2{v[0]*cos - v[1]*sin, v[0]*sin + v[1]*cos} }
+	hr := &polygonRegion{evenOdd: pr.evenOdd}
+	min, max := Vec2{math.Inf(1), math.Inf(1)}, Vec2{math.Inf(-1), math.Inf(-1)}
+	for _, r := range pr.rings {
+		ring := make([]Vec2, len(r))
+		for i, v := range r {
+			ring[i] = to(v)
+			for k := 0; k < 2; k++ {
+				min[k] = math.Min(min[k], ring[i][k])
+				max[k] = math.Max(max[k], ring[i][k])
+			}
+		}
+		hr.rings = append(hr.rings, ring)
+	}
+	es := hr.edges(nil)
+	joins := func(a, b Vec2) bool {
+		if a.Dist(b) > 4*spacing {
+			return false
+		}
+		if crossesEdges(a, b, es) {
+			return false
+		}
+		m := a.Lerp(b, 0.5)
+		return hr.contains(m) || onEdge(m, es)
+	}
+
+	var done, open []*hatchChain
+	for k := math.Ceil(min[1]/spacing - 0.5); (k+0.5)*spacing <= max[1]; k++ {
+		var next []*hatchChain
+		for _, piece := range row(hr, es, (k+0.5)*spacing, min[0], max[0]) {
+			l, r := piece[0], piece[len(piece)-1]
+			var best *hatchChain
+			bestI, bestD := -1, 0.0
+			if link {
+				for i, c := range open {
+					end := c.vs[len(c.vs)-1]
+					start := l
+					if c.right {
+						start = r
+					}
+					if d := end.Dist(start); (best == nil || d < bestD) && joins(end, start) {
+						best, bestI, bestD = c, i, d
+					}
+				}
+			}
+			if best == nil {
+				best = &hatchChain{right: int64(k)&1 == 0}
+			} else {
+				best.right = !best.right
+				open = append(open[:bestI], open[bestI+1:]...)
+			}
+			if best.right {
+				best.vs = append(best.vs, piece...)
+			} else {
+				for i := len(piece) - 1; i >= 0; i-- {
+					best.vs = append(best.vs, piece[i])
+				}
+			}
+			next = append(next, best)
+		}
+		done = append(done, open...)
+		open = next
+	}
+	done = append(done, open...)
+
+	var lines []Path
+	for _, c := range done {
+		var p Path
+		for _, v := range c.vs {
+			if len(p.V) == 0 || p.V[len(p.V)-1] != v {
+				p.V = append(p.V, v)
+			}
+		}
+		if len(p.V) < 2 {
+			continue
+		}
+		for i := range p.V {
+			p.V[i] = from(p.V[i])
+		}
+		lines = append(lines, p)
+	}
+	return lines
+}
+
+// Hatch adds parallel lines, spacing apart, that fill the area
+// enclosed by the polygon, which is made of one or more paths as in
+// ClipToPolygon. The lines are at angle theta (in radians) from the
+// x axis, turning towards the y axis. With cfg.Cross, the area is
+// hatched again with lines at right angles to the first, and with
+// cfg.Link, the lines are joined up to reduce pen lifts. The lines
+// have the attributes of the first path of the polygon. cfg may be
+// nil, and nothing is added if spacing isn't positive.
+func (ps *Paths) Hatch(poly []Path, theta, spacing float64, rule FillRule, cfg *HatchConfig) {
+	if spacing <= 0 || len(poly) == 0 {
+		return
+	}
+	if cfg == nil {
+		cfg = &HatchConfig{}
+	}
+	pr := polygonArea(poly, rule)
+	lines := hatchRows(pr, theta, spacing, cfg.Link, straightRow)
+	if cfg.Cross {
+		lines = append(lines, hatchRows(pr, theta+math.Pi/2, spacing, cfg.Link, straightRow)...)
+	}
+	for i := range lines {
+		lines[i].Attrs = poly[0].Attrs
+	}
+	ps.P = append(ps.P, lines...)
+}
@@ -0,0 +1,107 @@
+package paths
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+// pathsLength returns the total length of the paths, which must
+// only have straight lines.
+func pathsLength(ps []Path) float64 {
+	l := 0.0
+	for _, p := range ps {
+		for i := 1; i < len(p.V); i++ {
+			l += p.V[i].Dist(p.V[i-1])
+		}
+	}
+	return l
+}
+
+func TestHatchSquare(t *testing.T) {
+	square := RoundedRect(Bounds{Max: Vec2{10, 4}}, 0, 0)
+	square.Attrs = Attrs{Pen: 2}
+	ps := &Paths{}
+	ps.Hatch([]Path{square}, 0, 1, NonZero, nil)
+	want := []Path{
+		{V: []Vec2{{0, 0.5}, {10, 0.5}}, Attrs: square.Attrs},
+		{V: []Vec2{{10, 1.5}, {0, 1.5}}, Attrs: square.Attrs},
+		{V: []Vec2{{0, 2.5}, {10, 2.5}}, Attrs: square.Attrs},
+		{V: []Vec2{{10, 3.5}, {0, 3.5}}, Attrs: square.Attrs},
+	}
+	if !reflect.DeepEqual(ps.P, want) {
+		t.Errorf("hatched square is %v, want %v", ps.P, want)
+	}
+
+	ps = &Paths{}
+	ps.Hatch([]Path{square}, 0, 1, NonZero, &HatchConfig{Link: true})
+	want = []Path{{
+		V:     []Vec2{{0, 0.5}, {10, 0.5}, {10, 1.5}, {0, 1.5}, {0, 2.5}, {10, 2.5}, {10, 3.5}, {0, 3.5}},
+		Attrs: square.Attrs,
+	}}
+	if !reflect.DeepEqual(ps.P, want) {
+		t.Errorf("hatched and linked square is %v, want %v", ps.P, want)
+	}
+}
+
+func TestHatch(t *testing.T) {
+	star := Star(Vec2{}, 7, 10, 3, 0.1)
+	cases := []struct {
+		desc string
+		poly []Path
+		rule FillRule
+		area float64
+	}{
+		{"square", []Path{rect(0, 0, 10, 10)}, NonZero, 100},
+		{"circle", []Path{Circle(Vec2{3, 4}, 10)}, NonZero, math.Pi * 100},
+		{"star", []Path{star}, NonZero, 7 * 10 * 3 * math.Sin(math.Pi/7)},
+		{"hole, even-odd", []Path{rect(0, 0, 10, 10), rect(2, 2, 8, 8)}, EvenOdd, 64},
+		{"hole the same way round, nonzero", []Path{rect(0, 0, 10, 10), rect(2, 2, 8, 8)}, NonZero, 100},
+		{"hole the other way round, nonzero", []Path{rect(0, 0, 10, 10), reverse(rect(2, 2, 8, 8))}, NonZero, 64},
+	}
+	const spacing = 0.1
+	for _, c := range cases {
+		area := polygonArea(c.poly, c.rule)
+		edges := area.edges(nil)
+		for _, theta := range []float64{0, 0.3, math.Pi / 2, 2} {
+			for _, cfg := range []HatchConfig{{}, {Link: true}, {Cross: true}, {Cross: true, Link: true}} {
+				ps := &Paths{}
+				ps.Hatch(c.poly, theta, spacing, c.rule, &cfg)
+				var lines []Path
+				for _, p := range ps.P {
+					for i := 1; i < len(p.V); i++ {
+						d := p.V[i].Sub(p.V[i-1])
+						along := math.Abs(d[0]*math.Sin(theta)-d[1]*math.Cos(theta)) < 1e-9
+						across := math.Abs(d[0]*math.Cos(theta)+d[1]*math.Sin(theta)) < 1e-9
+						if along || (cfg.Cross && across) {
+							lines = append(lines, Path{V: p.V[i-1 : i+1]})
+						} else if !cfg.Link {
+							t.Errorf("%s at %g with %+v: line %v isn't at the hatching angle", c.desc, theta, cfg, p.V[i-1:i+1])
+						}
+						if m := p.V[i-1].Lerp(p.V[i], 0.5); !area.contains(m) && !onEdge(m, edges) {
+							t.Errorf("%s at %g with %+v: line %v goes outside", c.desc, theta, cfg, p.V[i-1:i+1])
+						}
+					}
+				}
+				// The lines cover the area. Links can be at the same
+				// angle as the lines, so they're only checked unlinked.
+				want := c.area / spacing
+				if cfg.Cross {
+					want *= 2
+				}
+				if got := pathsLength(lines); !cfg.Link && math.Abs(got-want) > 0.01*want {
+					t.Errorf("%s at %g with %+v: hatch lines have length %g, want about %g", c.desc, theta, cfg, got, want)
+				}
+				if cfg.Link && len(ps.P) >= len(lines)/5 {
+					t.Errorf("%s at %g with %+v: %d lines are only linked into %d paths", c.desc, theta, cfg, len(lines), len(ps.P))
+				}
+			}
+		}
+	}
+
+	ps := &Paths{}
+	ps.Hatch([]Path{rect(0, 0, 10, 10)}, 0, 0, NonZero, nil)
+	if len(ps.P) != 0 {
+		t.Errorf("hatching with no spacing gave %v, want nothing", ps.P)
+	}
+}
@@ -0,0 +1,17 @@
+package paths
+
+// rect returns the rectangle with corners (x0, y0) and (x1, y1),
+// going anticlockwise from (x0, y0).
+func rect(x0, y0, x1, y1 float64) Path {
+	return RoundedRect(Bounds{Min: Vec2{x0, y0}, Max: Vec2{x1, y1}}, 0, 0)
+}
+
+// line returns the line from (x0, y0) to (x1, y1).
+func line(x0, y0, x1, y1 float64) Path {
+	return Path{V: []Vec2{{x0, y0}, {x1, y1}}}
+}
+
+// reverse returns a copy of p going the other way.
+func reverse(p Path) Path {
+	return reverseLoops([]Path{p.clone()})[0]
+}
@@ -0,0 +1,151 @@
+package paths
+
+import "math"
+
+// A Matrix is a 2d affine transformation, represented as a 3x3
+// matrix that acts on homogeneous coordinates (x, y, 1).
+type Matrix struct {
+	M [3][3]float64
+}
+
+// Identity returns the matrix that leaves points where they are.
+func Identity() *Matrix {
+	return &Matrix{
+		M: [3][3]float64{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}},
+	}
+}
+
+// Translate returns the matrix that moves points by d.
+func Translate(d Vec2) *Matrix {
+	return &Matrix{
+		M: [3][3]float64{
+			{1, 0, d[0]},
+			{0, 1, d[1]},
+			{0, 0, 1},
+		},
+	}
+}
+
+// Scale returns the matrix that scales points about the origin by
+// x horizontally and y vertically.
+func Scale(x, y float64) *Matrix {
+	return &Matrix{
+		M: [3][3]float64{
+			{x, 0, 0},
+			{0, y, 0},
+			{0, 0, 1},
+		},
+	}
+}
+
+// Rotate returns the matrix that rotates points by theta radians
+// about c. Positive angles turn the x axis towards the y axis,
+// which is clockwise when y points down, as it does in SVG.
+func Rotate(theta float64, c Vec2) *Matrix {
+	cs, sn := math.Cos(theta), math.Sin(theta)
+	rot := &Matrix{
+		M: [3][3]float64{
+			{cs, -sn, 0},
+			{sn, cs, 0},
+			{0, 0, 1},
+		},
+	}
+	return Translate(c).Compose(rot).Compose(Translate(c.Scale(-1)))
+}
+
+// Skew returns the matrix that skews points by the angle ax (in
+// radians) along the x axis and ay along the y axis, like the SVG
+// skewX and skewY transforms.
+func Skew(ax, ay float64) *Matrix {
+	return &Matrix{
+		M: [3][3]float64{
+			{1, math.Tan(ax), 0},
+			{math.Tan(ay), 1, 0},
+			{0, 0, 1},
+		},
+	}
+}
+
+// Mirror returns the matrix that reflects points in the line
+// through a and b.
+func Mirror(a, b Vec2) *Matrix {
+	d := b.Sub(a)
+	l := d.Len()
+	if l == 0 {
+		return Identity()
+	}
+	d = d.Scale(1 / l)
+	ref := &Matrix{
+		M: [3][3]float64{
+			{d[0]*d[0] - d[1]*d[1], 2 * d[0] * d[1], 0},
+			{2 * d[0] * d[1], d[1]*d[1] - d[0]*d[0], 0},
+			{0, 0, 1},
+		},
+	}
+	return Translate(a).Compose(ref).Compose(Translate(a.Scale(-1)))
+}
+
+// Compose returns the matrix that applies m2 and then m.
+func (m *Matrix) Compose(m2 *Matrix) *Matrix {
+	var a Matrix
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			for k := 0; k < 3; k++ {
+				a.M[i][k] += m.M[i][j] * m2.M[j][k]
+			}
+		}
+	}
+	return &a
+}
+
+// Invert returns the inverse of m. It returns false if m has no
+// inverse, for example if it scales everything onto a line.
+func (m *Matrix) Invert() (*Matrix, bool) {
+	a := &m.M
+	// The inverse is the adjugate divided by the determinant.
+	var adj Matrix
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			i1, i2 := (j+1)%3, (j+2)%3
+			j1, j2 := (i+1)%3, (i+2)%3
+			adj.M[i][j] = a[i1][j1]*a[i2][j2] - a[i1][j2]*a[i2][j1]
+		}
+	}
+	det := a[0][0]*adj.M[0][0] + a[0][1]*adj.M[1][0] + a[0][2]*adj.M[2][0]
+	if det == 0 {
+		return nil, false
+	}
+	for i := range adj.M {
+		for j := range adj.M[i] {
+			adj.M[i][j] /= det
+		}
+	}
+	return &adj, true
+}
+
+// Apply returns v transformed by m.
+func (m *Matrix) Apply(v Vec2) Vec2 {
+	x := [3]float64{v[0], v[1], 1.0}
+	var r [3]float64
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			r[i] += m.M[i][j] * x[j]
+		}
+	}
+	return Vec2{r[0] / r[2], r[1] / r[2]}
+}
+
+// ApplyMatrix transforms all the paths by m. The bounds are
+// updated to an axis-aligned bounding box that contains the
+// transformed bounds.
+func (ps *Paths) ApplyMatrix(m *Matrix) {
+	for i := range ps.P {
+		ps.P[i].transform(m.Apply)
+	}
+	b := ps.Bounds
+	nb := Bounds{Min: m.Apply(b.Min), Max: m.Apply(b.Min)}
+	for _, v := range []Vec2{{b.Max[0], b.Min[1]}, b.Max, {b.Min[0], b.Max[1]}} {
+		nb = nb.Union(Bounds{Min: m.Apply(v), Max: m.Apply(v)})
+	}
+	ps.Bounds = nb
+}
@@ -0,0 +1,138 @@
+package paths
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMatrix(t *testing.T) {
+	cases := []struct {
+		desc string
+		m    *Matrix
+		in   Vec2
+		want Vec2
+	}{
+		{"identity", Identity(), Vec2{3, 4}, Vec2{3, 4}},
+		{"translate", Translate(Vec2{10, -5}), Vec2{3, 4}, Vec2{13, -1}},
+		{"scale", Scale(2, -1), Vec2{3, 4}, Vec2{6, -4}},
+		{"rotate about origin", Rotate(math.Pi/2, Vec2{}), Vec2{1, 0}, Vec2{0, 1}},
+		{"rotate about point", Rotate(math.Pi/2, Vec2{10, 10}), Vec2{20, 10}, Vec2{10, 20}},
+		{"rotate center is fixed", Rotate(1, Vec2{5, 7}), Vec2{5, 7}, Vec2{5, 7}},
+		{"skew x", Skew(math.Pi/4, 0), Vec2{1, 2}, Vec2{3, 2}},
+		{"skew y", Skew(0, math.Pi/4), Vec2{1, 2}, Vec2{1, 3}},
+		{"mirror x axis", Mirror(Vec2{0, 0}, Vec2{1, 0}), Vec2{3, 4}, Vec2{3, -4}},
+		{"mirror diagonal", Mirror(Vec2{0, 0}, Vec2{2, 2}), Vec2{3, 1}, Vec2{1, 3}},
+		{"mirror offset line", Mirror(Vec2{0, 10}, Vec2{5, 10}), Vec2{3, 4}, Vec2{3, 16}},
+		{"mirror degenerate", Mirror(Vec2{1, 1}, Vec2{1, 1}), Vec2{3, 4}, Vec2{3, 4}},
+		{"compose", Translate(Vec2{10, 0}).Compose(Scale(2, 2)), Vec2{1, 1}, Vec2{12, 2}},
+	}
+	for _, c := range cases {
+		if got := c.m.Apply(c.in); got.Dist(c.want) > 1e-9 {
+			t.Errorf("%s: Apply(%v) = %v, want %v", c.desc, c.in, got, c.want)
+		}
+		inv, ok := c.m.Invert()
+		if !ok {
+			t.Errorf("%s: Invert failed", c.desc)
+			continue
+		}
+		if got := inv.Apply(c.want); got.Dist(c.in) > 1e-9 {
+			t.Errorf("%s: inverse Apply(%v) = %v, want %v", c.desc, c.want, got, c.in)
+		}
+	}
+}
+
+func TestMatrixInvertSingular(t *testing.T) {
+	if _, ok := Scale(1, 0).Invert(); ok {
+		t.Errorf("Scale(1, 0).Invert() succeeded, want failure")
+	}
+}
+
+func TestApplyMatrix(t *testing.T) {
+	ps := &Paths{
+		Bounds: Bounds{Max: Vec2{20, 10}},
+		P: []Path{
+			{V: []Vec2{{0, 0}, {20, 10}}},
+			{
+				V: []Vec2{{10, 0}, {0, 10}},
+				S: []Segment{{Kind: ArcSegment, Arc: Arc{U: Vec2{10, 0}, V: Vec2{0, 10}, Sweep: math.Pi / 2}}},
+			},
+		},
+	}
+	m := Rotate(math.Pi/2, Vec2{})
+	want := ps.copy()
+	for i := range want.P {
+		want.P[i].Flatten(0.001)
+		for j, v := range want.P[i].V {
+			want.P[i].V[j] = m.Apply(v)
+		}
+	}
+	ps.ApplyMatrix(m)
+	if !curvesNear(ps.P, want.P, 0.01) {
+		t.Errorf("ApplyMatrix gave %v, want %v", ps.P, want.P)
+	}
+	wantBounds := Bounds{Min: Vec2{-10, 0}, Max: Vec2{0, 20}}
+	if ps.Bounds.Min.Dist(wantBounds.Min) > 1e-9 || ps.Bounds.Max.Dist(wantBounds.Max) > 1e-9 {
+		t.Errorf("ApplyMatrix gave bounds %v, want %v", ps.Bounds, wantBounds)
+	}
+}
+
+func TestBounds(t *testing.T) {
+	a := Bounds{Min: Vec2{0, 0}, Max: Vec2{10, 10}}
+	b := Bounds{Min: Vec2{5, -5}, Max: Vec2{20, 5}}
+	far := Bounds{Min: Vec2{50, 50}, Max: Vec2{60, 60}}
+
+	if got, want := a.Union(b), (Bounds{Min: Vec2{0, -5}, Max: Vec2{20, 10}}); got != want {
+		t.Errorf("Union = %v, want %v", got, want)
+	}
+	if got, want := a.Intersect(b), (Bounds{Min: Vec2{5, 0}, Max: Vec2{10, 5}}); got != want {
+		t.Errorf("Intersect = %v, want %v", got, want)
+	}
+	empty := a.Intersect(far)
+	if !empty.Empty() {
+		t.Errorf("Intersect of disjoint bounds = %v, want empty", empty)
+	}
+	if got := empty.Union(b); got != b {
+		t.Errorf("Union with empty bounds = %v, want %v", got, b)
+	}
+	if got, want := a.Center(), (Vec2{5, 5}); got != want {
+		t.Errorf("Center = %v, want %v", got, want)
+	}
+	if got, want := b.Size(), (Vec2{15, 10}); got != want {
+		t.Errorf("Size = %v, want %v", got, want)
+	}
+	if got, want := a.Expand(1), (Bounds{Min: Vec2{-1, -1}, Max: Vec2{11, 11}}); got != want {
+		t.Errorf("Expand(1) = %v, want %v", got, want)
+	}
+	if !a.Expand(-6).Empty() {
+		t.Errorf("Expand(-6) = %v, want empty", a.Expand(-6))
+	}
+	for _, c := range []struct {
+		v    Vec2
+		want bool
+	}{
+		{Vec2{5, 5}, true},
+		{Vec2{0, 10}, true},
+		{Vec2{-1, 5}, false},
+		{Vec2{5, 10.5}, false},
+	} {
+		if got := a.Contains(c.v); got != c.want {
+			t.Errorf("Contains(%v) = %v, want %v", c.v, got, c.want)
+		}
+	}
+}
+
+func TestVec2(t *testing.T) {
+	v, w := Vec2{3, 4}, Vec2{1, -2}
+	if got, want := v.Add(w), (Vec2{4, 2}); got != want {
+		t.Errorf("Add = %v, want %v", got, want)
+	}
+	if got, want := v.Sub(w), (Vec2{2, 6}); got != want {
+		t.Errorf("Sub = %v, want %v", got, want)
+	}
+	if got := v.Len(); got != 5 {
+		t.Errorf("Len = %v, want 5", got)
+	}
+	if got, want := v.Lerp(w, 0.5), (Vec2{2, 1}); got != want {
+		t.Errorf("Lerp = %v, want %v", got, want)
+	}
+}
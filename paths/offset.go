@@ -0,0 +1,695 @@
+package paths
+
+import (
+	"math"
+	"sort"
+)
+
+// A JoinStyle is the shape of the corners of offset paths, where
+// they go round the outside of a corner of the original path.
+type JoinStyle int
+
+const (
+	// MiterJoin makes sharp corners, but bevels corners that would
+	// stick out more than 4 times the offset distance, as SVG does
+	// by default.
+	MiterJoin JoinStyle = iota
+	// RoundJoin makes rounded corners, and rounds the ends of open
+	// paths.
+	RoundJoin
+	// BevelJoin cuts corners off.
+	BevelJoin
+)
+
+// miterLimit is the furthest a mitered corner can stick out, as a
+// multiple of the offset distance, before it's bevelled instead.
+const miterLimit = 4
+
+// A convex is a convex polygon.
+type convex struct {
+	ring     []Vec2    // anticlockwise
+	es       [][2]Vec2 // the edges of the ring
+	min, max Vec2      // bounding box of the ring
+}
+
+// newConvex returns the convex polygon with vertices ring, which
+// may go either way round. It returns nil if the polygon has no
+// area.
+func newConvex(ring []Vec2) *convex {
+	a := loopArea(append(ring[:len(ring):len(ring)], ring[0]))
+	if math.Abs(a) < 1e-12 {
+		return nil
+	}
+	if a < 0 {
+		for i, j := 0, len(ring)-1; i < j; i, j = i+1, j-1 {
+			ring[i], ring[j] = ring[j], ring[i]
+		}
+	}
+	c := &convex{ring: ring, min: ring[0], max: ring[0]}
+	for i, v := range ring {
+		c.es = append(c.es, [2]Vec2{v, ring[(i+1)%len(ring)]})
+		for k := 0; k < 2; k++ {
+			c.min[k] = math.Min(c.min[k], v[k])
+			c.max[k] = math.Max(c.max[k], v[k])
+		}
+	}
+	return c
+}
+
+// stadiumFacets returns how many lines the rounded end of a stadium
+// of radius d is flattened to, so that they're within curveTolerance
+// of the circle.
+func stadiumFacets(d float64) int {
+	// A line touching a circle of radius d, turning through angle h
+	// from one end to the other, is d(1/cos(h/2) - 1) from it at
+	// its ends.
+	h := 2 * math.Acos(d/(d+curveTolerance))
+	return int(math.Max(1, math.Ceil(math.Pi/h)))
+}
+
+// arc appends to vs the points of an arc of radius d around c, from
+// angle theta0 to theta1, flattened with n lines that touch the
+// circle from outside, so that they're at least d from c.
+func arc(vs []Vec2, c Vec2, d, theta0, theta1 float64, n int) []Vec2 {
+	h := (theta1 - theta0) / float64(n)
+	rr := d / math.Cos(h/2)
+	at := func(theta, r float64) Vec2 {
+		return Vec2{c[0] + r*math.Cos(theta), c[1] + r*math.Sin(theta)}
+	}
+	vs = append(vs, at(theta0, d))
+	for i := 0; i < n; i++ {
+		vs = append(vs, at(theta0+(float64(i)+0.5)*h, rr))
+	}
+	return append(vs, at(theta1, d))
+}
+
+// newStadium returns the area within d of the segment from a to b,
+// with n lines around each end. Its rounded ends are flattened with
+// lines that touch the circle from outside, so that all of its
+// boundary is at least d from the segment, and its straight sides
+// are exactly that far.
+func newStadium(a, b Vec2, d float64, n int) *convex {
+	u := b.Sub(a)
+	theta := math.Atan2(u[1], u[0])
+	ring := arc(nil, b, d, theta-math.Pi/2, theta+math.Pi/2, n)
+	return newConvex(arc(ring, a, d, theta+math.Pi/2, theta+3*math.Pi/2, n))
+}
+
+// overlaps reports whether the bounding boxes of the polygons meet.
+func (c *convex) overlaps(o *convex) bool {
+	return c.min[0] <= o.max[0] && o.min[0] <= c.max[0] && c.min[1] <= o.max[1] && o.min[1] <= c.max[1]
+}
+
+// strictlyContains reports whether v is inside the polygon, and
+// not on or very near its boundary.
+func (c *convex) strictlyContains(v Vec2) bool {
+	if v[0] <= c.min[0] || v[0] >= c.max[0] || v[1] <= c.min[1] || v[1] >= c.max[1] {
+		return false
+	}
+	for _, e := range c.es {
+		// v is cross/|f| to the left of the line through the edge,
+		// and must be more than 1e-9 to the left of all of them.
+		f := e[1].Sub(e[0])
+		if x := cross(e[0], e[1], v); x <= 0 || x*x <= 1e-18*(f[0]*f[0]+f[1]*f[1]) {
+			return false
+		}
+	}
+	return true
+}
+
+// onBoundary returns whether v is on the boundary of the polygon,
+// and if so, whether the boundary there goes in direction dir
+// rather than the opposite way.
+func (c *convex) onBoundary(v, dir Vec2) (on, same bool) {
+	for _, e := range c.es {
+		f := e[1].Sub(e[0])
+		// v is cross/|f| from the line through the edge, which is
+		// quicker to rule out than the distance from the edge.
+		if x := cross(e[0], e[1], v); x*x > 1e-18*(f[0]*f[0]+f[1]*f[1]) {
+			continue
+		}
+		if segmentDist(v, e[0], e[1]) < 1e-9 && math.Abs(cross(e[0], e[1], e[0].Add(dir))) < 1e-6*f.Len()*dir.Len() {
+			return true, f[0]*dir[0]+f[1]*dir[1] > 0
+		}
+	}
+	return false, false
+}
+
+// stadiums returns the stadiums of the points within d of each of
+// the edges es.
+func stadiums(es [][2]Vec2, d float64) []*convex {
+	n := stadiumFacets(d)
+	var cs []*convex
+	for _, e := range es {
+		if e[0] != e[1] {
+			cs = append(cs, newStadium(e[0], e[1], d, n))
+		}
+	}
+	return cs
+}
+
+// penShapes returns convex polygons that together make up the area
+// within d of the line through the vertices vs, which is a loop if
+// closed is set, with corners in the given style. Each line has a
+// rectangle on either side of it, each corner has a piece that
+// fills the gap between the rectangles on the outside of the corner,
+// and for RoundJoin, the ends of an open line are rounded.
+func penShapes(vs []Vec2, closed bool, d float64, join JoinStyle) []*convex {
+	pts := simplifyLine(vs, closed)
+	facets := stadiumFacets(d)
+	n := len(pts)
+	if n < 2 {
+		if n == 1 && join == RoundJoin {
+			return []*convex{newConvex(arc(nil, pts[0], d, 0, 2*math.Pi, 2*facets)[1:])}
+		}
+		return nil
+	}
+	edges := n - 1
+	if closed {
+		edges = n
+	}
+	// normal returns the left normal of the i'th edge, of length d.
+	normal := func(i int) Vec2 {
+		u := pts[(i+1)%n].Sub(pts[i])
+		return Vec2{-u[1], u[0]}.Scale(d / u.Len())
+	}
+	var cs []*convex
+	add := func(ring []Vec2) {
+		if c := newConvex(ring); c != nil {
+			cs = append(cs, c)
+		}
+	}
+	for i := 0; i < edges; i++ {
+		a, b, nu := pts[i], pts[(i+1)%n], normal(i)
+		add([]Vec2{a.Sub(nu), b.Sub(nu), b.Add(nu), a.Add(nu)})
+	}
+	for i := 0; i < n; i++ {
+		p := pts[i]
+		if !closed && (i == 0 || i == n-1) {
+			if join == RoundJoin {
+				// The end is rounded from the left of the line
+				// round the back to its right.
+				nu := normal(0)
+				if i == n-1 {
+					nu = normal(n - 2).Scale(-1)
+				}
+				theta := math.Atan2(nu[1], nu[0])
+				add(append(arc(nil, p, d, theta, theta+math.Pi, facets), p))
+			}
+			continue
+		}
+		n1, n2 := normal((i+edges-1)%edges), normal(i)
+		if n1 == n2 {
+			continue
+		}
+		// The gap is on the left of a right turn, where the normals
+		// turn clockwise, and on the right of a left turn.
+		dir := -1.0
+		if n1[0]*n2[1]-n1[1]*n2[0] > 0 {
+			n1, n2, dir = n1.Scale(-1), n2.Scale(-1), 1
+		}
+		switch cosPhi := (n1[0]*n2[0] + n1[1]*n2[1]) / (d * d); {
+		case join == RoundJoin:
+			phi := math.Acos(math.Max(-1, math.Min(1, cosPhi)))
+			m := roundJoinLines(pts[(i+n-1)%n], p, pts[(i+1)%n], phi, d)
+			theta := math.Atan2(n1[1], n1[0])
+			add(append(arc(nil, p, d, theta, theta+dir*phi, m), p))
+		case join == MiterJoin && 1+cosPhi >= 2/(miterLimit*miterLimit):
+			// The miter sticks out d/cos(phi/2), where phi is the
+			// angle between the normals, and cos^2(phi/2) is
+			// (1+cos(phi))/2.
+			m := p.Add(n1.Add(n2).Scale(1 / (1 + cosPhi)))
+			add([]Vec2{p, p.Add(n1), m, p.Add(n2)})
+		default:
+			add([]Vec2{p, p.Add(n1), p.Add(n2)})
+		}
+	}
+	return cs
+}
+
+// roundJoinLines returns how many lines a round join of radius d
+// is flattened to, at the corner at p between a and b, which turns
+// through phi.
+func roundJoinLines(a, p, b Vec2, phi, d float64) int {
+	// Where the corner is on a flattened curve of radius r, the
+	// join is part of a curve of radius r+d, which needs more lines
+	// to stay as close to it.
+	f := stadiumFacets(d)
+	if r := curveRadius(a, p, b, phi); r > 0 {
+		f = stadiumFacets(r + d)
+	}
+	return int(math.Max(1, math.Ceil(float64(f)*phi/math.Pi)))
+}
+
+// curveRadius returns the radius of the curve that the corner at p,
+// between a and b, which turns through phi, is on, if it looks like
+// one of the corners of a curve flattened to within curveTolerance.
+// It returns 0 if it's a sharp corner.
+func curveRadius(a, p, b Vec2, phi float64) float64 {
+	// The lines of a curve of radius r flattened with lines that
+	// touch it, each turning through phi, are 2r tan(phi/2) long,
+	// and their ends are r(1/cos(phi/2) - 1) from it.
+	r := math.Min(p.Dist(a), p.Dist(b)) / (2 * math.Tan(phi/2))
+	if r*(1/math.Cos(phi/2)-1) > 2*curveTolerance {
+		return 0
+	}
+	return r
+}
+
+// simplifyLine returns the vertices vs without any that are very
+// close to the line between their neighbours, which give lines and
+// corners whose directions are mostly rounding error. If closed is
+// set, vs is a loop, and its last vertex isn't repeated in the
+// result.
+func simplifyLine(vs []Vec2, closed bool) []Vec2 {
+	const eps = 1e-6
+	var pts []Vec2
+	for _, v := range vs {
+		if len(pts) > 0 && pts[len(pts)-1].Dist(v) < eps {
+			continue
+		}
+		if len(pts) >= 2 && segmentDist(pts[len(pts)-1], pts[len(pts)-2], v) < eps {
+			pts = pts[:len(pts)-1]
+		}
+		pts = append(pts, v)
+	}
+	if !closed {
+		return pts
+	}
+	if len(pts) > 1 && pts[0].Dist(pts[len(pts)-1]) < eps {
+		pts = pts[:len(pts)-1]
+	}
+	for len(pts) >= 3 && segmentDist(pts[0], pts[len(pts)-1], pts[1]) < eps {
+		pts = pts[1:]
+	}
+	for len(pts) >= 3 && segmentDist(pts[len(pts)-1], pts[len(pts)-2], pts[0]) < eps {
+		pts = pts[:len(pts)-1]
+	}
+	return pts
+}
+
+// segmentVertices appends to ts the positions along the segment
+// from a to b of any of the vertices vs that are on it, so that
+// where the segment runs along an edge, it's split where the edge
+// starts and ends.
+func segmentVertices(ts []float64, a, b Vec2, vs []Vec2) []float64 {
+	d := b.Sub(a)
+	dd := d[0]*d[0] + d[1]*d[1]
+	if dd == 0 {
+		return ts
+	}
+	for _, v := range vs {
+		if segmentDist(v, a, b) < 1e-9 {
+			if t := ((v[0]-a[0])*d[0] + (v[1]-a[1])*d[1]) / dd; t > 0 && t < 1 {
+				ts = append(ts, t)
+			}
+		}
+	}
+	return ts
+}
+
+// covered reports whether the point v on the boundary of the i'th
+// polygon, which goes in direction dir there, is strictly inside any
+// of the other polygons near it. Where the boundaries of two polygons
+// run along each other, only one of them is kept, and where they run
+// back to back, neither is.
+func covered(v, dir Vec2, cs []*convex, near []int, i int) bool {
+	for _, j := range near {
+		c := cs[j]
+		if j == i || v[0] < c.min[0]-1e-9 || v[0] > c.max[0]+1e-9 || v[1] < c.min[1]-1e-9 || v[1] > c.max[1]+1e-9 {
+			continue
+		}
+		if c.strictlyContains(v) {
+			return true
+		}
+		if on, same := c.onBoundary(v, dir); on && (!same || j < i) {
+			return true
+		}
+	}
+	return false
+}
+
+// outlineLoops returns the loops that bound the union of the convex
+// polygons cs, keeping only the parts of them where keep is true.
+// Outer boundaries go anticlockwise, and the boundaries of holes go
+// clockwise. Slivers left where polygons nearly line up are dropped,
+// as in pieceLoops.
+func outlineLoops(cs []*convex, keep func(v Vec2) bool) []Path {
+	return pieceLoops(rawPieces(cs, keep))
+}
+
+// pieceLoops joins the pieces into loops, as joinPieces does, and
+// splits any loop that comes back to a vertex it's already been
+// through into separate loops. Vertices that are in line with their
+// neighbours are left out, as are slivers, which have next to no
+// area, and anything that doesn't join up into a loop.
+func pieceLoops(pieces [][2]Vec2) []Path {
+	var loops []Path
+	for _, p := range joinPieces(pieces) {
+		if !p.Closed {
+			continue
+		}
+		for _, vs := range splitLoop(p.V) {
+			vs = simplifyLine(vs, true)
+			if len(vs) < 3 {
+				continue
+			}
+			vs = append(vs, vs[0])
+			l := 0.0
+			for i := 1; i < len(vs); i++ {
+				l += vs[i].Dist(vs[i-1])
+			}
+			if math.Abs(loopArea(vs)) > 1e-6*l {
+				loops = append(loops, Path{V: vs, Closed: true})
+			}
+		}
+	}
+	return loops
+}
+
+// splitLoop splits the loop vs, whose last vertex is the same as its
+// first, wherever it comes back to a vertex it's been through, into
+// loops that don't. The loops it returns don't repeat their first
+// vertex at the end.
+func splitLoop(vs []Vec2) [][]Vec2 {
+	const eps = 1e-7
+	var loops [][]Vec2
+	var stack []Vec2
+	for _, v := range vs[:len(vs)-1] {
+		j := len(stack) - 1
+		for ; j >= 0 && stack[j].Dist(v) >= eps; j-- {
+		}
+		if j < 0 {
+			stack = append(stack, v)
+			continue
+		}
+		loops = append(loops, append([]Vec2{}, stack[j:]...))
+		stack = stack[:j+1]
+	}
+	return append(loops, stack)
+}
+
+// rawPieces returns the pieces of the edges of the convex polygons cs
+// that are on the outline of their union and where keep is true.
+func rawPieces(cs []*convex, keep func(v Vec2) bool) [][2]Vec2 {
+	var pieces [][2]Vec2
+	var ts []float64
+	var near []int
+	boxes := make([]Bounds, len(cs))
+	for i, c := range cs {
+		boxes[i] = Bounds{Min: c.min, Max: c.max}
+	}
+	g := newBoxGrid(boxes)
+	eps := Vec2{1e-9, 1e-9}
+	for i, c := range cs {
+		near = g.near(near[:0], c.min.Sub(eps), c.max.Add(eps))
+		for _, e := range c.es {
+			ts = ts[:0]
+			for _, j := range near {
+				if o := cs[j]; j != i && c.overlaps(o) {
+					ts = segmentCrossings(ts, e[0], e[1], o.es)
+					ts = segmentVertices(ts, e[0], e[1], o.ring)
+				}
+			}
+			ts = append(ts, 0, 1)
+			sort.Float64s(ts)
+			for k := 1; k < len(ts); k++ {
+				if ts[k]-ts[k-1] < 1e-12 {
+					continue
+				}
+				m := e[0].Lerp(e[1], (ts[k-1]+ts[k])/2)
+				if covered(m, e[1].Sub(e[0]), cs, near, i) || !keep(m) {
+					continue
+				}
+				pieces = append(pieces, [2]Vec2{e[0].Lerp(e[1], ts[k-1]), e[0].Lerp(e[1], ts[k])})
+			}
+		}
+	}
+	return pieces
+}
+
+// reverseLoops reverses the direction of each of the paths ps.
+func reverseLoops(ps []Path) []Path {
+	for _, p := range ps {
+		for a, b := 0, len(p.V)-1; a < b; a, b = a+1, b-1 {
+			p.V[a], p.V[b] = p.V[b], p.V[a]
+		}
+	}
+	return ps
+}
+
+// insetLoops returns the loops that bound the points inside r that
+// are at least d from its edges. Outer boundaries go anticlockwise,
+// and holes go clockwise. There are none if nothing is that far
+// inside.
+func insetLoops(r region, d float64) []Path {
+	// The boundary is made of the parts of the boundaries of the
+	// stadiums that are inside r, and not inside another stadium.
+	// They go round the stadiums, so the wrong way round the inset.
+	return reverseLoops(outlineLoops(stadiums(r.edges(nil), d), r.contains))
+}
+
+// joinPieces joins line segments that meet end to start into paths,
+// leaving out vertices that are very close to the last. Paths that
+// end where they start are closed. Where more than one piece starts
+// where a path has got to, it takes the one that turns furthest to
+// the left, so that where the area on the left of the pieces touches
+// itself at a point, the paths go round each part of it separately.
+func joinPieces(pieces [][2]Vec2) []Path {
+	const eps = 1e-7
+	type cell [2]int64
+	at := func(v Vec2) cell {
+		return cell{int64(math.Floor(v[0] / eps)), int64(math.Floor(v[1] / eps))}
+	}
+	starts := map[cell][]int{}
+	for i, p := range pieces {
+		c := at(p[0])
+		starts[c] = append(starts[c], i)
+	}
+	used := make([]bool, len(pieces))
+	// next finds the unused piece that starts at v and turns furthest
+	// to the left from direction dir.
+	next := func(v, dir Vec2) int {
+		c := at(v)
+		best, bestTurn := -1, 0.0
+		for dx := int64(-1); dx <= 1; dx++ {
+			for dy := int64(-1); dy <= 1; dy++ {
+				for _, i := range starts[cell{c[0] + dx, c[1] + dy}] {
+					if used[i] || pieces[i][0].Dist(v) >= eps {
+						continue
+					}
+					u := pieces[i][1].Sub(pieces[i][0])
+					turn := math.Atan2(dir[0]*u[1]-dir[1]*u[0], dir[0]*u[0]+dir[1]*u[1])
+					if best < 0 || turn > bestTurn {
+						best, bestTurn = i, turn
+					}
+				}
+			}
+		}
+		return best
+	}
+	// Where a path gets stuck, it carries on along any earlier path
+	// that starts there, which can happen where pieces branch.
+	var ps []*Path
+	earlier := map[cell][]int{}
+	follow := func(v Vec2) int {
+		c := at(v)
+		for dx := int64(-1); dx <= 1; dx++ {
+			for dy := int64(-1); dy <= 1; dy++ {
+				for _, i := range earlier[cell{c[0] + dx, c[1] + dy}] {
+					if ps[i] != nil && ps[i].V[0].Dist(v) < eps {
+						return i
+					}
+				}
+			}
+		}
+		return -1
+	}
+	for i := range pieces {
+		if used[i] {
+			continue
+		}
+		used[i] = true
+		p := &Path{V: []Vec2{pieces[i][0], pieces[i][1]}}
+		dir := pieces[i][1].Sub(pieces[i][0])
+		for {
+			end := p.V[len(p.V)-1]
+			if j := next(end, dir); j >= 0 {
+				used[j] = true
+				dir = pieces[j][1].Sub(pieces[j][0])
+				if v := pieces[j][1]; v.Dist(end) >= eps {
+					p.V = append(p.V, v)
+				}
+			} else if j := follow(end); j >= 0 && end.Dist(p.V[0]) >= eps {
+				p.V = append(p.V, ps[j].V[1:]...)
+				ps[j] = nil
+				dir = p.V[len(p.V)-1].Sub(p.V[len(p.V)-2])
+			} else {
+				break
+			}
+		}
+		if n := len(p.V); n > 3 && p.V[n-1].Dist(p.V[0]) < eps {
+			p.V[n-1] = p.V[0]
+			p.Closed = true
+		} else {
+			c := at(p.V[0])
+			earlier[c] = append(earlier[c], len(ps))
+		}
+		ps = append(ps, p)
+	}
+	var out []Path
+	for _, p := range ps {
+		if p != nil {
+			out = append(out, *p)
+		}
+	}
+	return out
+}
+
+// loopArea returns the signed area of the loop v, whose last vertex
+// is the same as its first. It's positive if the loop goes
+// anticlockwise.
+func loopArea(v []Vec2) float64 {
+	a := 0.0
+	for i := 1; i < len(v); i++ {
+		a += v[i-1][0]*v[i][1] - v[i][0]*v[i-1][1]
+	}
+	return a / 2
+}
+
+// offsetArea returns the loops that bound the area pr grown by d, or
+// shrunk if d is negative, with corners on the outside of the loops
+// in the given style. Outer boundaries go anticlockwise, and holes
+// go clockwise.
+func offsetArea(pr *polygonRegion, d float64, join JoinStyle) []Path {
+	var cs []*convex
+	for _, ring := range pr.rings {
+		cs = append(cs, penShapes(ring, true, math.Abs(d), join)...)
+	}
+	if d > 0 {
+		return outlineLoops(cs, complementRegion{pr}.contains)
+	}
+	return reverseLoops(outlineLoops(cs, pr.contains))
+}
+
+// parallelLine returns the line d to the left of the line through
+// the vertices vs, or -d to its right if d is negative. Corners on
+// the outside are shaped by join, and on the inside, the line is cut
+// short where its sides meet. It returns nil if vs is a single point.
+func parallelLine(vs []Vec2, d float64, join JoinStyle) []Vec2 {
+	pts := simplifyLine(vs, false)
+	n := len(pts)
+	if n < 2 {
+		return nil
+	}
+	ad := math.Abs(d)
+	// normal returns the normal of the i'th edge, of length |d|, on
+	// the side of the parallel line.
+	normal := func(i int) Vec2 {
+		u := pts[i+1].Sub(pts[i])
+		return Vec2{-u[1], u[0]}.Scale(d / u.Len())
+	}
+	out := []Vec2{pts[0].Add(normal(0))}
+	for i := 1; i < n-1; i++ {
+		p, n1, n2 := pts[i], normal(i-1), normal(i)
+		cosPhi := (n1[0]*n2[0] + n1[1]*n2[1]) / (d * d)
+		// The normals turn the same way as the line, and the
+		// corner is on the outside of the parallel line where the
+		// line turns away from it, which is right if it's on the
+		// left.
+		turn := n1[0]*n2[1] - n1[1]*n2[0]
+		outside := turn*d < 0
+		switch {
+		case !outside && 1+cosPhi > 1e-9, join == MiterJoin && 1+cosPhi >= 2/(miterLimit*miterLimit):
+			// The sides meet, or are mitered, d/cos(phi/2) from
+			// the corner, as in penShapes.
+			out = append(out, p.Add(n1.Add(n2).Scale(1/(1+cosPhi))))
+		case outside && join == RoundJoin:
+			phi := math.Atan2(turn, n1[0]*n2[0]+n1[1]*n2[1])
+			m := roundJoinLines(pts[i-1], p, pts[i+1], math.Abs(phi), ad)
+			theta := math.Atan2(n1[1], n1[0])
+			out = arc(out, p, ad, theta, theta+phi, m)
+		default:
+			out = append(out, p.Add(n1), p.Add(n2))
+		}
+	}
+	return append(out, pts[n-1].Add(normal(n-2)))
+}
+
+// Offset grows or shrinks the closed paths by d, and moves the open
+// paths d to their left, as for cutting with a tool of radius d.
+// Closed paths with the same attributes are taken together as the
+// boundary of an area, as in ClipToPolygon with the given rule. If d
+// is positive, the area grows by d all round, and if it's negative,
+// it shrinks, and any parts narrower than twice the distance vanish.
+// The outlines are closed paths, going anticlockwise around areas and
+// clockwise around holes. Open paths are replaced by the line d to
+// their left, or -d to their right if d is negative, which is cut
+// short on the inside of corners. Corners on the outside of the new
+// paths are shaped by join, and round corners are flattened to
+// within 0.05. The new paths have the attributes of the paths they
+// came from.
+func (ps *Paths) Offset(d float64, rule FillRule, join JoinStyle) {
+	if d == 0 {
+		return
+	}
+	groups := map[Attrs][]Path{}
+	for _, p := range ps.P {
+		if p.Closed {
+			groups[p.Attrs] = append(groups[p.Attrs], p)
+		}
+	}
+	var out []Path
+	for _, p := range ps.P {
+		if !p.Closed {
+			if p.curved() {
+				p = p.clone()
+				p.Flatten(curveTolerance)
+			}
+			if vs := parallelLine(p.V, d, join); vs != nil {
+				out = append(out, Path{V: vs, Attrs: p.Attrs})
+			}
+			continue
+		}
+		// Each area is outlined where its first path was.
+		group, ok := groups[p.Attrs]
+		if !ok {
+			continue
+		}
+		delete(groups, p.Attrs)
+		loops := offsetArea(polygonArea(group, rule), d, join)
+		for i := range loops {
+			loops[i].Attrs = p.Attrs
+		}
+		out = append(out, loops...)
+	}
+	ps.P = out
+}
+
+// Outline replaces each of the paths with the outline of the area
+// within d of it, as for drawing it with a thick line. Corners on the
+// outside of the outlines are shaped by join, and for RoundJoin, the
+// ends of open paths are rounded too. Round corners are flattened to
+// within 0.05. The outlines are closed paths, going anticlockwise
+// around areas and clockwise around holes, with the attributes of
+// the paths they came from. The sign of d doesn't matter, and
+// nothing changes if it's 0.
+func (ps *Paths) Outline(d float64, join JoinStyle) {
+	if d == 0 {
+		return
+	}
+	everywhere := func(Vec2) bool { return true }
+	var out []Path
+	for _, p := range ps.P {
+		if p.curved() {
+			p = p.clone()
+			p.Flatten(curveTolerance)
+		}
+		loops := outlineLoops(penShapes(p.V, p.Closed, math.Abs(d), join), everywhere)
+		for i := range loops {
+			loops[i].Attrs = p.Attrs
+		}
+		out = append(out, loops...)
+	}
+	ps.P = out
+}
@@ -0,0 +1,260 @@
+package paths
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// netArea returns the area enclosed by the closed paths, counting
+// clockwise paths as holes.
+func netArea(ps []Path) float64 {
+	a := 0.0
+	for _, p := range ps {
+		a += loopArea(p.V)
+	}
+	return a
+}
+
+func TestOffset(t *testing.T) {
+	square := rect(0, 0, 10, 10)
+	hole := reverse(rect(3, 3, 7, 7))
+	// Round corners are flattened outside the arcs, so they have a
+	// little more area than they would otherwise.
+	round := func(a, arcs float64) [2]float64 {
+		return [2]float64{a, a + arcs*2*math.Pi*(1+curveTolerance)*curveTolerance}
+	}
+	exact := func(a float64) [2]float64 { return [2]float64{a, a} }
+	cases := []struct {
+		desc  string
+		ps    []Path
+		rule  FillRule
+		d     float64
+		join  JoinStyle
+		loops int
+		area  [2]float64 // the least and most area
+	}{
+		{"square, mitered", []Path{square}, NonZero, 1, MiterJoin, 1, exact(144)},
+		{"square, bevelled", []Path{square}, NonZero, 1, BevelJoin, 1, exact(142)},
+		{"square, rounded", []Path{square}, NonZero, 1, RoundJoin, 1, round(140+math.Pi, 1)},
+		{"square inset, mitered", []Path{square}, NonZero, -1, MiterJoin, 1, exact(64)},
+		{"square inset, rounded", []Path{square}, NonZero, -1, RoundJoin, 1, exact(64)},
+		{"square vanishes", []Path{square}, NonZero, -5.5, RoundJoin, 0, exact(0)},
+		{"square with hole", []Path{square, hole}, NonZero, 1, MiterJoin, 2, exact(144 - 4)},
+		{"square with hole, rounded", []Path{square, hole}, NonZero, 1, RoundJoin, 2, round(140+math.Pi-4, 1)},
+		{"hole grows", []Path{square, hole}, NonZero, -1, MiterJoin, 2, exact(64 - 36)},
+		{"hole vanishes", []Path{square, hole}, NonZero, 2.5, MiterJoin, 1, exact(225)},
+		{"hole going the same way, non-zero", []Path{square, rect(3, 3, 7, 7)}, NonZero, 1, MiterJoin, 1, exact(144)},
+		{"hole going the same way, even-odd", []Path{square, rect(3, 3, 7, 7)}, EvenOdd, 1, MiterJoin, 2, exact(144 - 4)},
+		{"overlapping squares", []Path{rect(0, 0, 4, 4), rect(2, 2, 6, 6)}, NonZero, 1, MiterJoin, 1, exact(56)},
+		{"separate squares join", []Path{rect(0, 0, 4, 4), rect(5, 0, 9, 4)}, NonZero, 1, MiterJoin, 1, exact(66)},
+		{"pentagram", []Path{Star(Vec2{}, 5, 10, 10, 0.3)}, NonZero, 0.5, RoundJoin, 1, [2]float64{50, 400}},
+	}
+	for _, c := range cases {
+		ps := &Paths{}
+		for _, p := range c.ps {
+			p = p.clone()
+			p.Attrs = Attrs{Pen: 2}
+			ps.P = append(ps.P, p)
+		}
+		ps.Offset(c.d, c.rule, c.join)
+		if len(ps.P) != c.loops {
+			t.Errorf("%s: offset to %d paths, want %d", c.desc, len(ps.P), c.loops)
+		}
+		for _, p := range ps.P {
+			if !p.Closed || p.Attrs != (Attrs{Pen: 2}) {
+				t.Errorf("%s: offset to path %v, closed %v with attributes %+v, want it closed with the original attributes", c.desc, p.V, p.Closed, p.Attrs)
+			}
+		}
+		if a := netArea(ps.P); a < c.area[0]-1e-6 || a > c.area[1]+1e-6 {
+			t.Errorf("%s: offset has area %g, want between %g and %g", c.desc, a, c.area[0], c.area[1])
+		}
+	}
+
+	ps := &Paths{P: []Path{square.clone()}}
+	ps.Offset(0, NonZero, MiterJoin)
+	if !shapesNear(ps.P, []Path{square}, 0) {
+		t.Errorf("offset by 0 gave %v, want the square unchanged", ps.P)
+	}
+}
+
+func TestOffsetLines(t *testing.T) {
+	horizontal := line(0, 0, 10, 0)
+	corner := Path{V: []Vec2{{0, 0}, {10, 0}, {10, 10}}}
+	cases := []struct {
+		desc string
+		p    Path
+		d    float64
+		join JoinStyle
+		want []Path
+	}{
+		{"left", horizontal, 1, MiterJoin, []Path{line(0, 1, 10, 1)}},
+		{"right", horizontal, -1, RoundJoin, []Path{line(0, -1, 10, -1)}},
+		{"inside corner", corner, 1, RoundJoin, []Path{{V: []Vec2{{0, 1}, {9, 1}, {9, 10}}}}},
+		{"outside corner, mitered", corner, -1, MiterJoin, []Path{{V: []Vec2{{0, -1}, {11, -1}, {11, 10}}}}},
+		{"outside corner, bevelled", corner, -1, BevelJoin, []Path{{V: []Vec2{{0, -1}, {10, -1}, {11, 0}, {11, 10}}}}},
+		{"point", Path{V: []Vec2{{1, 1}}}, 1, RoundJoin, nil},
+	}
+	for _, c := range cases {
+		p := c.p.clone()
+		p.Attrs = Attrs{Pen: 2}
+		ps := &Paths{P: []Path{p}}
+		ps.Offset(c.d, NonZero, c.join)
+		if !shapesNear(ps.P, c.want, 1e-9) {
+			t.Errorf("%s: offset to %v, want %v", c.desc, ps.P, c.want)
+		}
+		for _, p := range ps.P {
+			if p.Closed || p.Attrs != (Attrs{Pen: 2}) {
+				t.Errorf("%s: offset to path %v, closed %v with attributes %+v, want it open with the original attributes", c.desc, p.V, p.Closed, p.Attrs)
+			}
+		}
+	}
+
+	// A round corner goes round the outside of the corner, between
+	// the ends of the two sides.
+	ps := &Paths{P: []Path{corner.clone()}}
+	ps.Offset(-1, NonZero, RoundJoin)
+	if len(ps.P) != 1 || len(ps.P[0].V) < 5 {
+		t.Fatalf("offset round the outside of a corner is %v, want one path round it", ps.P)
+	}
+	vs := ps.P[0].V
+	if n := len(vs); vs[0] != (Vec2{0, -1}) || vs[1].Dist(Vec2{10, -1}) > 1e-9 || vs[n-2].Dist(Vec2{11, 0}) > 1e-9 || vs[n-1] != (Vec2{11, 10}) {
+		t.Errorf("offset round the outside of a corner is %v, want it from (0,-1) to (10,-1), round to (11,0) and on to (11,10)", vs)
+	}
+	for _, v := range vs[1 : len(vs)-1] {
+		if d := v.Dist(Vec2{10, 0}); d < 1-1e-9 || d > 1+curveTolerance+1e-9 || v[0] < 10-1e-9 || v[1] > 1e-9 {
+			t.Errorf("offset round the outside of a corner has %v, %g from the corner, want it about 1 from it", v, d)
+		}
+	}
+}
+
+func TestOutline(t *testing.T) {
+	square := rect(0, 0, 10, 10)
+	horizontal := line(0, 0, 10, 0)
+	corner := Path{V: []Vec2{{0, 0}, {10, 0}, {10, 10}}}
+	round := func(a, arcs float64) [2]float64 {
+		return [2]float64{a, a + arcs*2*math.Pi*(1+curveTolerance)*curveTolerance}
+	}
+	exact := func(a float64) [2]float64 { return [2]float64{a, a} }
+	cases := []struct {
+		desc  string
+		p     Path
+		d     float64
+		join  JoinStyle
+		loops int
+		area  [2]float64 // the least and most area
+	}{
+		{"line", horizontal, 1, MiterJoin, 1, exact(20)},
+		{"line, rounded", horizontal, -1, RoundJoin, 1, round(20+math.Pi, 1)},
+		{"corner, mitered", corner, 1, MiterJoin, 1, exact(40)},
+		{"corner, bevelled", corner, 1, BevelJoin, 1, exact(39.5)},
+		{"corner, rounded", corner, 1, RoundJoin, 1, round(39+1.25*math.Pi, 1.25)},
+		{"point", Path{V: []Vec2{{1, 1}}}, 1, RoundJoin, 1, round(math.Pi, 1)},
+		{"square", square, 1, MiterJoin, 2, exact(144 - 64)},
+		{"square, rounded", square, 1, RoundJoin, 2, round(140+math.Pi-64, 1)},
+	}
+	for _, c := range cases {
+		p := c.p.clone()
+		p.Attrs = Attrs{Pen: 2}
+		ps := &Paths{P: []Path{p}}
+		ps.Outline(c.d, c.join)
+		if len(ps.P) != c.loops {
+			t.Errorf("%s: outlined with %d paths, want %d", c.desc, len(ps.P), c.loops)
+		}
+		for _, p := range ps.P {
+			if !p.Closed || p.Attrs != (Attrs{Pen: 2}) {
+				t.Errorf("%s: outlined with path %v, closed %v with attributes %+v, want it closed with the original attributes", c.desc, p.V, p.Closed, p.Attrs)
+			}
+		}
+		if a := netArea(ps.P); a < c.area[0]-1e-6 || a > c.area[1]+1e-6 {
+			t.Errorf("%s: outline has area %g, want between %g and %g", c.desc, a, c.area[0], c.area[1])
+		}
+	}
+}
+
+// randomPolygon returns an anticlockwise polygon with n vertices,
+// at random distances around c, which is inside it. n must be at
+// least 4.
+func randomPolygon(r *rand.Rand, c Vec2, n int) Path {
+	var p Path
+	for i := 0; i < n; i++ {
+		theta := (float64(i) + 0.8*r.Float64()) * 2 * math.Pi / float64(n)
+		d := 2 + 8*r.Float64()
+		p.V = append(p.V, c.Add(Vec2{d * math.Cos(theta), d * math.Sin(theta)}))
+	}
+	p.V = append(p.V, p.V[0])
+	p.Closed = true
+	return p
+}
+
+func TestOffsetRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		poly := randomPolygon(r, Vec2{}, 4+r.Intn(12))
+		d := 0.1 + 2*r.Float64()
+		area := netArea([]Path{poly})
+		es := polygonArea([]Path{poly}, NonZero).edges(nil)
+		offset := func(ps []Path, d float64, join JoinStyle) []Path {
+			out := &Paths{P: append([]Path{}, ps...)}
+			out.Offset(d, NonZero, join)
+			return out.P
+		}
+		for _, join := range []JoinStyle{MiterJoin, RoundJoin, BevelJoin} {
+			desc := fmt.Sprintf("polygon %d offset by %g with join %d", i, d, join)
+			out, in := offset([]Path{poly}, d, join), offset([]Path{poly}, -d, join)
+			// Offsetting out grows the area, and in shrinks it.
+			if a := netArea(out); a < area {
+				t.Errorf("%s: offset out has area %g, less than the polygon's %g", desc, a, area)
+			}
+			if a := netArea(in); a > area || a < 0 {
+				t.Errorf("%s: offset in has area %g, want between 0 and the polygon's %g", desc, a, area)
+			}
+			// Offsetting in by less takes away less.
+			if a, b := netArea(in), netArea(offset([]Path{poly}, -d/2, join)); a > b+1e-9 {
+				t.Errorf("%s: offset in has area %g, more than %g when offset half as far", desc, a, b)
+			}
+			// Every vertex is on the right side of the polygon.
+			// Except where corners are bevelled, they're at least
+			// the distance from it, and with round joins, no
+			// further than they need to be.
+			for _, o := range []struct {
+				ps   []Path
+				side bool
+			}{{out, false}, {in, true}} {
+				pr := polygonArea([]Path{poly}, NonZero)
+				for _, p := range o.ps {
+					for _, v := range p.V {
+						dist := edgeDist(v, es)
+						if (join != BevelJoin && dist < d-1e-6) || pr.contains(v) != o.side || (join == RoundJoin && dist > d+curveTolerance+1e-6) {
+							t.Errorf("%s: vertex %v is %g from the polygon, inside %v", desc, v, dist, pr.contains(v))
+						}
+					}
+				}
+			}
+		}
+
+		// Closing (offsetting out and then back in) and opening
+		// (the other way round) with round joins are idempotent.
+		for _, sign := range []float64{1, -1} {
+			once := offset(offset([]Path{poly}, sign*d, RoundJoin), -sign*d, RoundJoin)
+			twice := offset(offset(once, sign*d, RoundJoin), -sign*d, RoundJoin)
+			if a, b := netArea(once), netArea(twice); math.Abs(a-b) > 0.02*area {
+				t.Errorf("polygon %d offset by %g and back: area %g, and %g when repeated", i, sign*d, a, b)
+			}
+		}
+	}
+
+	// A convex polygon is unchanged by closing.
+	for i := 0; i < 10; i++ {
+		poly := Circle(Vec2{}, 2+8*r.Float64())
+		poly.Flatten(0.5)
+		d := 0.1 + 2*r.Float64()
+		ps := &Paths{P: []Path{poly}}
+		ps.Offset(d, NonZero, MiterJoin)
+		ps.Offset(-d, NonZero, MiterJoin)
+		if a, b := netArea(ps.P), netArea([]Path{poly}); len(ps.P) != 1 || math.Abs(a-b) > 1e-6 {
+			t.Errorf("convex polygon %d offset by %g and back has area %g in %d paths, want %g in 1", i, d, a, len(ps.P), b)
+		}
+	}
+}
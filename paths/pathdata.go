@@ -0,0 +1,110 @@
+package paths
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// A PathDataError describes a syntax error in the d attribute
+// of an SVG path element.
+type PathDataError struct {
+	ID     string // the id of the path element, if it has one
+	Offset int    // the byte offset in the d attribute of the error
+	Text   string // the text found at the offset
+	Reason string // what's wrong
+}
+
+func (e *PathDataError) Error() string {
+	where := "path"
+	if e.ID != "" {
+		where = fmt.Sprintf("path %q", e.ID)
+	}
+	text := "end of data"
+	if e.Text != "" {
+		text = fmt.Sprintf("%q", e.Text)
+	}
+	return fmt.Sprintf("%s: offset %d of d attribute (at %s): %s", where, e.Offset, text, e.Reason)
+}
+
+// pathLexer splits SVG path data into commands, numbers and flags.
+// Numbers are separated by whitespace and at most one comma, but
+// need no separator if the next number starts with a sign or a
+// decimal point that can't be part of the previous one, so
+// "10-5" is two numbers, as is "0.5.5".
+type pathLexer struct {
+	s   string
+	pos int
+	id  string // the id of the element, for errors
+}
+
+func isPathSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+// skipSpace skips whitespace.
+func (pl *pathLexer) skipSpace() {
+	for pl.pos < len(pl.s) && isPathSpace(pl.s[pl.pos]) {
+		pl.pos++
+	}
+}
+
+// skipSeparator skips whitespace and at most one comma, which
+// may follow an argument.
+func (pl *pathLexer) skipSeparator() {
+	pl.skipSpace()
+	if pl.pos < len(pl.s) && pl.s[pl.pos] == ',' {
+		pl.pos++
+		pl.skipSpace()
+	}
+}
+
+// eof reports whether all the path data has been read.
+func (pl *pathLexer) eof() bool {
+	return pl.pos >= len(pl.s)
+}
+
+// peek returns the next byte of path data.
+func (pl *pathLexer) peek() byte {
+	return pl.s[pl.pos]
+}
+
+// errorf returns a PathDataError at the given offset.
+func (pl *pathLexer) errorf(pos int, format string, args ...interface{}) error {
+	// The offending text extends to the next separator, up to a
+	// reasonable length.
+	end := pos
+	for end < len(pl.s) && end-pos < 16 && (end == pos || !isPathSpace(pl.s[end]) && pl.s[end] != ',') {
+		end++
+	}
+	return &PathDataError{
+		ID:     pl.id,
+		Offset: pos,
+		Text:   pl.s[pos:end],
+		Reason: fmt.Sprintf(format, args...),
+	}
+}
+
+// number reads a number, or if flag is set an arc flag, which is a
+// single 0 or 1. Any separator after it is skipped.
+func (pl *pathLexer) number(flag bool) (float64, error) {
+	start := pl.pos
+	if flag {
+		if c := pl.peek(); c != '0' && c != '1' {
+			return 0, pl.errorf(start, "expected arc flag (0 or 1)")
+		}
+		pl.pos++
+		pl.skipSeparator()
+		return float64(pl.s[start] - '0'), nil
+	}
+	n := scanNumber(pl.s[pl.pos:])
+	if n == 0 {
+		return 0, pl.errorf(start, "expected number")
+	}
+	f, err := strconv.ParseFloat(pl.s[start:start+n], 64)
+	if err != nil {
+		return 0, pl.errorf(start, "bad number: %v", err.(*strconv.NumError).Err)
+	}
+	pl.pos += n
+	pl.skipSeparator()
+	return f, nil
+}
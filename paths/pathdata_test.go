@@ -0,0 +1,79 @@
+package paths
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestPathData(t *testing.T) {
+	// Path data as written by various exporters, and an
+	// equivalent written out in full.
+	cases := []struct {
+		source string
+		d      string
+		want   string
+	}{
+		{"inkscape relative", "m 10,10 h 20 v 20 h -20 z", "M 10 10 L 30 10 30 30 10 30 Z"},
+		{"inkscape absolute", "M 10,10 H 30 V 30 H 10 Z", "M 10 10 L 30 10 30 30 10 30 Z"},
+		{"inkscape arcs", "m 50,50 a 10,10 0 0 1 -10,10 10,10 0 0 1 -10,-10", "M 50 50 A 10 10 0 0 1 40 60 A 10 10 0 0 1 30 50"},
+		{"inkscape curves", "m 10,50 c 10,-10 20,-10 30,0 10,10 20,10 30,0", "M 10 50 C 20 40 30 40 40 50 C 50 60 60 60 70 50"},
+		{"illustrator", "M10,10h20v20H10V10z", "M 10 10 L 30 10 30 30 10 30 10 10 Z"},
+		{"illustrator curves", "M3.5,11.2c0.3-0.2,0.7-0.2,1,0", "M 3.5 11.2 C 3.8 11 4.2 11 4.5 11.2"},
+		{"illustrator arcs", "M20,20a5,5,0,0,1-5,5", "M 20 20 A 5 5 0 0 1 15 25"},
+		{"illustrator packed", "M0.5.5l10-5", "M 0.5 0.5 L 10.5 -4.5"},
+		{"figma", "M10 10L30 10L30 30L10 30Z", "M 10 10 L 30 10 30 30 10 30 Z"},
+		{"figma rect", "M0.5 0.5H10.5V10.5H0.5V0.5Z", "M 0.5 0.5 L 10.5 0.5 10.5 10.5 0.5 10.5 0.5 0.5 Z"},
+		{"matplotlib", "M 72 38.8 \nL 51.4 38.8 \nL 51.4 4.32 \nz\n", "M 72 38.8 L 51.4 38.8 51.4 4.32 Z"},
+		{"matplotlib exponents", "M 1e1 2E+1 L 3.5e1 -4e-1", "M 10 20 L 35 -0.4"},
+		{"svgo packed flags", "M20 20a5 5 0 01-5 5", "M 20 20 A 5 5 0 0 1 15 25"},
+		{"svgo packed flags and numbers", "M20 20a5 5 0 1110 10", "M 20 20 A 5 5 0 1 1 30 30"},
+		{"plus signs", "M+10+10L+20+20", "M 10 10 L 20 20"},
+		{"leading points", "M.5.5L-.5-.5", "M 0.5 0.5 L -0.5 -0.5"},
+		{"exponent then sign", "M1e1-1e1", "M 10 -10"},
+		{"tabs and newlines", "M\t10\n10\r\nL20 20", "M 10 10 L 20 20"},
+		{"empty", "", ""},
+		{"spaces only", "  ", ""},
+	}
+	for _, tc := range cases {
+		got := pathFromD(t, tc.d)
+		want := pathFromD(t, tc.want)
+		if !pathsNear(got, want) {
+			t.Errorf("%s: path %q gives %v, want %v", tc.source, tc.d, got.P, want.P)
+		}
+	}
+}
+
+func TestPathDataErrors(t *testing.T) {
+	cases := []struct {
+		d      string
+		offset int
+		text   string
+	}{
+		{"M 10 10 L 20", 12, ""},
+		{"M 10 10 L 20 M 5 5", 13, "M"},
+		{"M 10 10 X 5 5", 8, "X"},
+		{"L 10 10", 0, "L"},
+		{"10 10", 0, "10"},
+		{"M 10 10 L 20 20 z 30 30", 18, "30"},
+		{"M 10 10 A 5 5 0 2 1 20 20", 16, "2"},
+		{"M 10 10 L 20 .", 13, "."},
+		{"M 10 10 L 20,,20", 13, ",20"},
+		{"M 10 10 L 20 1e999", 13, "1e999"},
+	}
+	for _, tc := range cases {
+		svg := `<svg width="100mm" height="100mm"><path id="p1" d="` + tc.d + `"/></svg>`
+		_, err := FromSVG(strings.NewReader(svg))
+		var pe *PathDataError
+		if !errors.As(err, &pe) {
+			t.Errorf("%q: got error %v, want a PathDataError", tc.d, err)
+			continue
+		}
+		if pe.ID != "p1" || pe.Offset != tc.offset || pe.Text != tc.text {
+			t.Errorf("%q: got error at id %q offset %d text %q, want id %q offset %d text %q", tc.d, pe.ID, pe.Offset, pe.Text, "p1", tc.offset, tc.text)
+		}
+		if !strings.Contains(err.Error(), `path "p1"`) {
+			t.Errorf("%q: error %q doesn't mention the path's id", tc.d, err)
+		}
+	}
+}
@@ -1,5 +1,5 @@
 // Package paths provides tools for manipulating 2d paths consisting
-// of line segments.
+// of line segments and curves.
 package paths
 
 import "math"
@@ -7,10 +7,29 @@ import "math"
 // Vec2 is a 2-dimensional vector.
 type Vec2 [2]float64
 
-// A Path is a contiguous series of line segments, from the
-// first point in the V slice to the last.
+// A Path is a contiguous series of segments, from the first point
+// in the V slice to the last. The segment from V[i] to V[i+1] is
+// a straight line, unless S is set and S[i] says otherwise.
+//
+// If Closed is set, the path is a loop, such as a polygon or the
+// outline of a shape: its last vertex is the same as its first,
+// and it may be drawn starting from any of its vertices.
 type Path struct {
-	V []Vec2
+	V      []Vec2
+	S      []Segment // if set, len(S) == len(V)-1
+	Closed bool
+	Attrs  Attrs
+}
+
+// Attrs describes how a path is to be drawn, and where it came
+// from. The zero Attrs draws with the default pen at the default
+// speed.
+type Attrs struct {
+	Layer string  // the name of the layer the path is on
+	Pen   int     // the pen to draw with, numbered from 1, or 0 for the current pen
+	Color string  // the stroke colour in #rrggbb form, or empty if it's not known
+	Feed  float64 // the feed rate (in mm/min) to draw with, or 0 for the default
+	ID    string  // the id of the SVG element the path came from
 }
 
 // Bounds describes an axis-aligned bounding box.
@@ -31,13 +50,27 @@ func (ps *Paths) TightenBounds() {
 	min := Vec2{inf, inf}
 	max := Vec2{-inf, -inf}
 	i := 0
+	var ts []float64
 	for _, p := range ps.P {
-		for _, v := range p.V {
+		for j, v := range p.V {
 			i++
 			min[0] = math.Min(min[0], v[0])
 			min[1] = math.Min(min[1], v[1])
 			max[0] = math.Max(max[0], v[0])
 			max[1] = math.Max(max[1], v[1])
+			if j == 0 || p.S == nil {
+				continue
+			}
+			// Curves may bulge beyond their end points.
+			s := &p.S[j-1]
+			ts = s.extremes(ts[:0], p.V[j-1], v)
+			for _, t := range ts {
+				e := s.point(p.V[j-1], v, t)
+				min[0] = math.Min(min[0], e[0])
+				min[1] = math.Min(min[1], e[1])
+				max[0] = math.Max(max[0], e[0])
+				max[1] = math.Max(max[1], e[1])
+			}
 		}
 	}
 	if i == 0 {
@@ -54,46 +87,19 @@ func (ps *Paths) TightenBounds() {
 func (ps *Paths) Translate(dx Vec2) {
 	b := ps.Bounds
 	nb := Bounds{
-		Min: vec2AddVec2(b.Min, dx),
-		Max: vec2AddVec2(b.Max, dx),
+		Min: b.Min.Add(dx),
+		Max: b.Max.Add(dx),
 	}
 	ps.Transform(nb)
 }
 
 // Rotate rotates all paths by the given angle (in radians)
-// about the center of the bounds.
+// about the center of the bounds. Positive angles are
+// anticlockwise when y points down.
 // The bounds are updated to an axis-aligned bounding box
 // that contains the original (rotated) bounds.
 func (ps *Paths) Rotate(theta float64) {
-	cx := (ps.Bounds.Min[0] + ps.Bounds.Max[0]) / 2
-	cy := (ps.Bounds.Min[1] + ps.Bounds.Max[1]) / 2
-	t0 := svgXformTranslate(-cx, -cy)
-	rot := svgXformRotate(theta)
-	t1 := svgXformTranslate(cx, cy)
-	m := t1.Compose(rot).Compose(t0)
-	for _, p := range ps.P {
-		for i, v := range p.V {
-			p.V[i] = m.Apply(v)
-		}
-	}
-
-	// Also mutate bounding box
-	bounds := [2]Vec2{ps.Bounds.Min, ps.Bounds.Max}
-	inf := math.Inf(1)
-	min := Vec2{inf, inf}
-	max := Vec2{-inf, -inf}
-
-	for i := 0; i < 2; i++ {
-		for j := 0; j < 2; j++ {
-			v := m.Apply(Vec2{bounds[i][0], bounds[j][1]})
-			for k := 0; k < 2; k++ {
-				min[k] = math.Min(min[k], v[k])
-				max[k] = math.Max(max[k], v[k])
-			}
-		}
-	}
-	ps.Bounds.Min = min
-	ps.Bounds.Max = max
+	ps.ApplyMatrix(Rotate(-theta, ps.Bounds.Center()))
 }
 
 // Transform resizes all paths so that the rectangle forming the
@@ -101,40 +107,40 @@ func (ps *Paths) Rotate(theta float64) {
 // are also updated to the new bounds.
 func (ps *Paths) Transform(nb Bounds) {
 	ob := ps.Bounds
-	for _, p := range ps.P {
-		for i, v := range p.V {
-			x, y := v[0], v[1]
-			x -= ob.Min[0]
-			x /= ob.Max[0] - ob.Min[0]
-			x *= nb.Max[0] - nb.Min[0]
-			x += nb.Min[0]
+	f := func(v Vec2) Vec2 {
+		x, y := v[0], v[1]
+		x -= ob.Min[0]
+		x /= ob.Max[0] - ob.Min[0]
+		x *= nb.Max[0] - nb.Min[0]
+		x += nb.Min[0]
 
-			y -= ob.Min[1]
-			y /= ob.Max[1] - ob.Min[1]
-			y *= nb.Max[1] - nb.Min[1]
-			y += nb.Min[1]
-			p.V[i] = [2]float64{x, y}
-		}
+		y -= ob.Min[1]
+		y /= ob.Max[1] - ob.Min[1]
+		y *= nb.Max[1] - nb.Min[1]
+		y += nb.Min[1]
+		return [2]float64{x, y}
+	}
+	for i := range ps.P {
+		ps.P[i].transform(f)
 	}
 	ps.Bounds = nb
 }
 
-// move adds a new (initially empty) path starting at x,
-// unless the last path already ends at x.
-func (ps *Paths) move(x Vec2) {
-	if len(ps.P) == 0 {
-		ps.P = append(ps.P, Path{V: []Vec2{x}})
-		return
-	}
-	p := &ps.P[len(ps.P)-1]
-	if len(p.V) > 0 && p.V[len(p.V)-1] == x {
-		return
+// move adds a new (initially empty) path with attributes a
+// starting at x, unless the last path already ends at x, has the
+// same attributes, and isn't closed.
+func (ps *Paths) move(x Vec2, a Attrs) {
+	if len(ps.P) > 0 {
+		p := &ps.P[len(ps.P)-1]
+		if len(p.V) > 0 && p.V[len(p.V)-1] == x && p.Attrs == a && !p.Closed {
+			return
+		}
 	}
-	ps.P = append(ps.P, Path{V: []Vec2{x}})
+	ps.P = append(ps.P, Path{V: []Vec2{x}, Attrs: a})
 }
 
-// line extends the last path with an edge that goes to x.
-func (ps *Paths) line(x Vec2) {
-	p := &ps.P[len(ps.P)-1]
-	p.V = append(p.V, x)
+// segment extends the last path with an edge of shape s that goes
+// to x.
+func (ps *Paths) segment(x Vec2, s Segment) {
+	ps.P[len(ps.P)-1].add(x, s)
 }
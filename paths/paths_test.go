@@ -1,6 +1,7 @@
 package paths
 
 import (
+	"math"
 	"reflect"
 	"testing"
 )
@@ -16,7 +17,7 @@ func (ps *Paths) copy() *Paths {
 	psc := &Paths{}
 	psc.Bounds = ps.Bounds
 	for _, p := range ps.P {
-		psc.P = append(psc.P, Path{V: append([]Vec2{}, p.V...)})
+		psc.P = append(psc.P, p.clone())
 	}
 	return psc
 }
@@ -70,3 +71,72 @@ func TestTransform(t *testing.T) {
 		}
 	}
 }
+
+func TestTransformCurves(t *testing.T) {
+	p := Path{
+		V: []Vec2{{0, 0}, {20, 0}, {40, 20}, {40, 40}},
+		S: []Segment{
+			{Kind: CubicSegment, C: [2]Vec2{{5, 10}, {15, 10}}},
+			{Kind: ArcSegment, Arc: Arc{Center: Vec2{20, 20}, U: Vec2{0, -20}, V: Vec2{20, 0}, Sweep: math.Pi / 2}},
+			{Kind: QuadSegment, C: [2]Vec2{{50, 30}}},
+		},
+	}
+	b := Bounds{Max: Vec2{50, 50}}
+	cases := []struct {
+		desc string
+		f    func(ps *Paths)
+		want func(v Vec2) Vec2
+	}{
+		{
+			desc: "transform",
+			f:    func(ps *Paths) { ps.Transform(Bounds{Min: Vec2{10, 20}, Max: Vec2{110, 70}}) },
+			want: func(v Vec2) Vec2 { return Vec2{10 + 2*v[0], 20 + v[1]} },
+		},
+		{
+			desc: "rotate",
+			f:    func(ps *Paths) { ps.Rotate(math.Pi / 2) },
+			want: func(v Vec2) Vec2 { return Vec2{v[1], 50 - v[0]} },
+		},
+	}
+	for _, c := range cases {
+		ps := &Paths{Bounds: b, P: []Path{p.clone()}}
+		c.f(ps)
+		got := samplePath(&ps.P[0])
+		for i, v := range samplePath(&p) {
+			if want := c.want(v); got[i].Dist(want) > 1e-9 {
+				t.Errorf("%s: point %v of curve moved to %v, want %v", c.desc, v, got[i], want)
+			}
+		}
+	}
+}
+
+func TestAttrsSurvive(t *testing.T) {
+	a := Attrs{Layer: "outline", Pen: 2, Color: "#ff0000", Feed: 500, ID: "path1"}
+	zigzag := Path{V: []Vec2{{-10, 0}, {10, 0}, {10, 1}, {10.01, 2}, {10, 3}, {-10, 3}}, Attrs: a}
+	loop := Circle(Vec2{0, 10}, 5)
+	loop.Attrs = a
+	ps := &Paths{Bounds: Bounds{Min: Vec2{-20, -20}, Max: Vec2{20, 20}}, P: []Path{zigzag, loop}}
+
+	check := func(op string) {
+		if len(ps.P) == 0 {
+			t.Fatalf("after %s, there are no paths", op)
+		}
+		for i, p := range ps.P {
+			if p.Attrs != a {
+				t.Errorf("after %s, path %d has attributes %+v, want %+v", op, i, p.Attrs, a)
+			}
+		}
+	}
+	ps.Transform(Bounds{Min: Vec2{0, 0}, Max: Vec2{40, 40}})
+	check("Transform")
+	ps.ApplyMatrix(Rotate(0.1, Vec2{20, 20}))
+	check("ApplyMatrix")
+	ps.Clip(Bounds{Min: Vec2{5, 5}, Max: Vec2{35, 35}})
+	check("Clip")
+	ps.Flatten(0.1)
+	check("Flatten")
+	ps.Simplify(0.1)
+	check("Simplify")
+	ps.Sort(&SortConfig{Split: true, Reverse: true})
+	check("Sort")
+}
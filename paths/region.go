@@ -0,0 +1,389 @@
+package paths
+
+import (
+	"math"
+	"sort"
+)
+
+// A region is an area of the plane that paths can be clipped to.
+type region interface {
+	// contains reports whether v is inside the region.
+	contains(v Vec2) bool
+	// edges appends the line segments that make up the
+	// boundary of the region to es.
+	edges(es [][2]Vec2) [][2]Vec2
+}
+
+// polygonRegion is the area inside a set of polygons, which
+// may overlap or intersect themselves. With the even-odd rule,
+// a point is inside if a ray from it crosses the polygons an odd
+// number of times; otherwise it's inside if the polygons wind
+// around it a non-zero number of times.
+type polygonRegion struct {
+	rings   [][]Vec2 // each ring is implicitly closed
+	evenOdd bool
+}
+
+func (pr *polygonRegion) contains(v Vec2) bool {
+	w := 0
+	for _, r := range pr.rings {
+		for i := range r {
+			a, b := r[i], r[(i+1)%len(r)]
+			if a[1] <= v[1] {
+				if b[1] > v[1] && cross(a, b, v) > 0 {
+					w++
+				}
+			} else if b[1] <= v[1] && cross(a, b, v) < 0 {
+				w--
+			}
+		}
+	}
+	if pr.evenOdd {
+		return w%2 != 0
+	}
+	return w != 0
+}
+
+func (pr *polygonRegion) edges(es [][2]Vec2) [][2]Vec2 {
+	for _, r := range pr.rings {
+		for i := range r {
+			es = append(es, [2]Vec2{r[i], r[(i+1)%len(r)]})
+		}
+	}
+	return es
+}
+
+// A FillRule decides which points are inside a set of polygons
+// that overlap, or that intersect themselves.
+type FillRule int
+
+const (
+	// NonZero counts a point as inside if the polygons wind around
+	// it a non-zero number of times, so a hole must go the opposite
+	// way round to the polygon that contains it.
+	NonZero FillRule = iota
+	// EvenOdd counts a point as inside if a ray from it crosses the
+	// polygons an odd number of times, so any polygon inside another
+	// is a hole.
+	EvenOdd
+)
+
+// polygonArea returns the region enclosed by the paths using the
+// given fill rule. Each path is joined back to its start, and
+// curves are flattened.
+func polygonArea(poly []Path, rule FillRule) *polygonRegion {
+	pr := &polygonRegion{evenOdd: rule == EvenOdd}
+	for _, p := range poly {
+		if p.curved() {
+			p = p.clone()
+			p.Flatten(curveTolerance)
+		}
+		if len(p.V) >= 3 {
+			pr.rings = append(pr.rings, p.V)
+		}
+	}
+	return pr
+}
+
+// boundsRegion is the area inside a rectangle, including its edges.
+type boundsRegion Bounds
+
+func (br boundsRegion) contains(v Vec2) bool {
+	return v[0] >= br.Min[0] && v[0] <= br.Max[0] && v[1] >= br.Min[1] && v[1] <= br.Max[1]
+}
+
+func (br boundsRegion) edges(es [][2]Vec2) [][2]Vec2 {
+	c := [4]Vec2{br.Min, {br.Max[0], br.Min[1]}, br.Max, {br.Min[0], br.Max[1]}}
+	for i := range c {
+		es = append(es, [2]Vec2{c[i], c[(i+1)%4]})
+	}
+	return es
+}
+
+// unionRegion contains the points that are in any of its regions.
+type unionRegion []region
+
+func (ur unionRegion) contains(v Vec2) bool {
+	for _, r := range ur {
+		if r.contains(v) {
+			return true
+		}
+	}
+	return false
+}
+
+func (ur unionRegion) edges(es [][2]Vec2) [][2]Vec2 {
+	for _, r := range ur {
+		es = r.edges(es)
+	}
+	return es
+}
+
+// intersectRegion contains the points that are in all of its regions.
+type intersectRegion []region
+
+func (ir intersectRegion) contains(v Vec2) bool {
+	for _, r := range ir {
+		if !r.contains(v) {
+			return false
+		}
+	}
+	return true
+}
+
+func (ir intersectRegion) edges(es [][2]Vec2) [][2]Vec2 {
+	for _, r := range ir {
+		es = r.edges(es)
+	}
+	return es
+}
+
+// stackRegion is a stack of regions painted on top of each other,
+// each of which either shows or hides what's beneath it. A point
+// is inside if the topmost region that contains it shows.
+type stackRegion struct {
+	layers []region
+	show   []bool
+}
+
+func (sr *stackRegion) contains(v Vec2) bool {
+	for i := len(sr.layers) - 1; i >= 0; i-- {
+		if sr.layers[i].contains(v) {
+			return sr.show[i]
+		}
+	}
+	return false
+}
+
+func (sr *stackRegion) edges(es [][2]Vec2) [][2]Vec2 {
+	for _, r := range sr.layers {
+		es = r.edges(es)
+	}
+	return es
+}
+
+// intersect returns the intersection of two regions,
+// either of which may be nil, meaning the whole plane.
+func intersect(a, b region) region {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	return intersectRegion{a, b}
+}
+
+// cross returns the z component of (b-a) x (v-a), which is
+// positive if v is to the left of the line from a to b.
+func cross(a, b, v Vec2) float64 {
+	return (b[0]-a[0])*(v[1]-a[1]) - (b[1]-a[1])*(v[0]-a[0])
+}
+
+// segmentCrossings appends to ts the positions along the segment
+// from a to b (as fractions of its length, strictly between 0 and 1)
+// where it crosses the edges es.
+func segmentCrossings(ts []float64, a, b Vec2, es [][2]Vec2) []float64 {
+	d := Vec2{b[0] - a[0], b[1] - a[1]}
+	for _, e := range es {
+		if math.Max(e[0][0], e[1][0]) < math.Min(a[0], b[0]) ||
+			math.Min(e[0][0], e[1][0]) > math.Max(a[0], b[0]) ||
+			math.Max(e[0][1], e[1][1]) < math.Min(a[1], b[1]) ||
+			math.Min(e[0][1], e[1][1]) > math.Max(a[1], b[1]) {
+			continue
+		}
+		f := Vec2{e[1][0] - e[0][0], e[1][1] - e[0][1]}
+		den := d[0]*f[1] - d[1]*f[0]
+		if den == 0 {
+			// Parallel lines. If they overlap, splitting at the
+			// ends of the edge keeps the pieces either on the
+			// boundary or clear of it.
+			if cross(a, b, e[0]) != 0 {
+				continue
+			}
+			dd := d[0]*d[0] + d[1]*d[1]
+			if dd == 0 {
+				continue
+			}
+			for _, v := range e {
+				t := ((v[0]-a[0])*d[0] + (v[1]-a[1])*d[1]) / dd
+				if t > 0 && t < 1 {
+					ts = append(ts, t)
+				}
+			}
+			continue
+		}
+		g := Vec2{e[0][0] - a[0], e[0][1] - a[1]}
+		t := (g[0]*f[1] - g[1]*f[0]) / den
+		u := (g[0]*d[1] - g[1]*d[0]) / den
+		if t > 0 && t < 1 && u >= 0 && u <= 1 {
+			ts = append(ts, t)
+		}
+	}
+	return ts
+}
+
+// curveCrossings appends to ts the positions along the curved
+// segment s from a to b (strictly between 0 and 1) where it crosses
+// the edges es. The curve is sampled, and wherever it passes from
+// one side of an edge's line to the other between two samples,
+// the crossing is found by bisection.
+func curveCrossings(ts []float64, s *Segment, a, b Vec2, es [][2]Vec2) []float64 {
+	// The bounding box of the curve is used to skip distant edges.
+	min, max := a, b
+	ext := append(s.extremes(nil, a, b), 1)
+	for _, t := range ext {
+		v := s.point(a, b, t)
+		for k := 0; k < 2; k++ {
+			min[k] = math.Min(min[k], math.Min(a[k], v[k]))
+			max[k] = math.Max(max[k], math.Max(a[k], v[k]))
+		}
+	}
+	n := 64
+	if s.Kind == ArcSegment {
+		n = int(math.Max(16, math.Ceil(math.Abs(s.Arc.Sweep)/(math.Pi/32))))
+	}
+	var samples []Vec2
+	for _, e := range es {
+		if math.Max(e[0][0], e[1][0]) < min[0] || math.Min(e[0][0], e[1][0]) > max[0] ||
+			math.Max(e[0][1], e[1][1]) < min[1] || math.Min(e[0][1], e[1][1]) > max[1] {
+			continue
+		}
+		f := Vec2{e[1][0] - e[0][0], e[1][1] - e[0][1]}
+		ff := f[0]*f[0] + f[1]*f[1]
+		if ff == 0 {
+			continue
+		}
+		if samples == nil {
+			for i := 0; i <= n; i++ {
+				samples = append(samples, s.point(a, b, float64(i)/float64(n)))
+			}
+		}
+		// found records a crossing at t if it's within the edge.
+		found := func(t float64) {
+			if t <= 0 || t >= 1 {
+				return
+			}
+			v := s.point(a, b, t)
+			u := ((v[0]-e[0][0])*f[0] + (v[1]-e[0][1])*f[1]) / ff
+			if u >= -1e-9 && u <= 1+1e-9 {
+				ts = append(ts, t)
+			}
+		}
+		prev := cross(e[0], e[1], samples[0])
+		for i := 1; i <= n; i++ {
+			cur := cross(e[0], e[1], samples[i])
+			if cur == 0 {
+				found(float64(i) / float64(n))
+			} else if prev != 0 && (prev < 0) != (cur < 0) {
+				lo, hi := float64(i-1)/float64(n), float64(i)/float64(n)
+				for k := 0; k < 60; k++ {
+					mid := (lo + hi) / 2
+					if (cross(e[0], e[1], s.point(a, b, mid)) < 0) == (prev < 0) {
+						lo = mid
+					} else {
+						hi = mid
+					}
+				}
+				found((lo + hi) / 2)
+			}
+			prev = cur
+		}
+	}
+	return ts
+}
+
+// clipToRegion returns the parts of the paths that are inside r.
+// Each segment is split where it crosses the boundary of the region,
+// and the pieces are kept if their midpoints are inside.
+func clipToRegion(ps []Path, r region) []Path {
+	es := r.edges(nil)
+	var result []Path
+	var ts []float64
+	for _, p := range ps {
+		if len(p.V) == 1 {
+			if r.contains(p.V[0]) {
+				result = append(result, p)
+			}
+			continue
+		}
+		var parts []Path
+		cur := Path{Attrs: p.Attrs}
+		var split bool   // whether the last vertex of cur splits this segment
+		var from float64 // where the last piece of cur starts in this segment
+		flush := func() {
+			if len(cur.V) >= 2 {
+				parts = append(parts, cur)
+			}
+			cur = Path{Attrs: p.Attrs}
+		}
+		for i := 1; i < len(p.V); i++ {
+			a, b := p.V[i-1], p.V[i]
+			s := p.segment(i - 1)
+			split, from = false, 0
+			if s.Kind == LineSegment {
+				ts = segmentCrossings(ts[:0], a, b, es)
+			} else {
+				ts = curveCrossings(ts[:0], &s, a, b, es)
+			}
+			ts = append(ts, 0, 1)
+			sort.Float64s(ts)
+			for j := 1; j < len(ts); j++ {
+				t0, t1 := ts[j-1], ts[j]
+				if t1-t0 < 1e-12 {
+					continue
+				}
+				if !r.contains(s.point(a, b, (t0+t1)/2)) {
+					flush()
+					continue
+				}
+				v0, v1 := s.point(a, b, t0), s.point(a, b, t1)
+				if t0 == 0 {
+					v0 = a
+				}
+				if t1 == 1 {
+					v1 = b
+				}
+				if len(cur.V) == 0 {
+					cur.add(v0, Segment{})
+				} else if split {
+					// Extend the previous piece of this segment.
+					cur.V = cur.V[:len(cur.V)-1]
+					if cur.S != nil {
+						cur.S = cur.S[:len(cur.S)-1]
+					}
+					t0 = from
+				}
+				cur.add(v1, s.split(a, b, t0, t1))
+				from = t0
+				split = t1 != 1
+			}
+		}
+		flush()
+		result = append(result, joinSeam(&p, parts)...)
+	}
+	return result
+}
+
+// joinSeam tidies up the parts that are left after clipping the
+// path p, if it's a loop. If the loop has been cut, the pieces
+// either side of its start are joined together, and if it's
+// entirely inside, it stays Closed.
+func joinSeam(p *Path, parts []Path) []Path {
+	n := len(p.V)
+	if len(parts) == 0 || p.V[0] != p.V[n-1] {
+		return parts
+	}
+	first, last := parts[0], parts[len(parts)-1]
+	if first.V[0] != p.V[0] || last.V[len(last.V)-1] != p.V[n-1] {
+		return parts
+	}
+	if len(parts) == 1 {
+		parts[0].Closed = p.Closed
+		return parts
+	}
+	joined := last.clone()
+	joined.extend(&first)
+	parts[0] = joined
+	return parts[:len(parts)-1]
+}
@@ -0,0 +1,264 @@
+package paths
+
+import "math"
+
+// A SegmentKind is the shape of a segment of a path.
+type SegmentKind int
+
+const (
+	LineSegment  SegmentKind = iota // a straight line
+	QuadSegment                     // a quadratic bezier, with control point C[0]
+	CubicSegment                    // a cubic bezier, with control points C[0] and C[1]
+	ArcSegment                      // part of an ellipse, described by Arc
+)
+
+// A Segment describes the shape of a path between two consecutive
+// vertices. The zero Segment is a straight line.
+type Segment struct {
+	Kind SegmentKind
+	C    [2]Vec2
+	Arc  Arc
+}
+
+// point returns the point at t (from 0 to 1) along the segment,
+// which goes from a to b.
+func (s *Segment) point(a, b Vec2, t float64) Vec2 {
+	switch s.Kind {
+	case QuadSegment:
+		return a.Lerp(s.C[0], t).Lerp(s.C[0].Lerp(b, t), t)
+	case CubicSegment:
+		return cubicBlossom(a, s.C[0], s.C[1], b, t, t, t)
+	case ArcSegment:
+		return s.Arc.point(s.Arc.Start + s.Arc.Sweep*t)
+	}
+	return a.Lerp(b, t)
+}
+
+// cubicBlossom returns the blossom of the cubic bezier with control
+// points p0, p1, p2, p3 at u, v, w. The blossom at t, t, t is the
+// point at t, and the control points of the part of the curve from
+// t0 to t1 are the blossoms at (t0, t0, t0), (t0, t0, t1),
+// (t0, t1, t1) and (t1, t1, t1).
+func cubicBlossom(p0, p1, p2, p3 Vec2, u, v, w float64) Vec2 {
+	a, b, c := p0.Lerp(p1, u), p1.Lerp(p2, u), p2.Lerp(p3, u)
+	return a.Lerp(b, v).Lerp(b.Lerp(c, v), w)
+}
+
+// split returns the part of the segment from a to b between t0
+// and t1. It goes from s.point(a, b, t0) to s.point(a, b, t1).
+func (s *Segment) split(a, b Vec2, t0, t1 float64) Segment {
+	switch s.Kind {
+	case QuadSegment:
+		a0, c0 := a.Lerp(s.C[0], t0), s.C[0].Lerp(b, t0)
+		return Segment{Kind: QuadSegment, C: [2]Vec2{a0.Lerp(c0, t1)}}
+	case CubicSegment:
+		return Segment{Kind: CubicSegment, C: [2]Vec2{
+			cubicBlossom(a, s.C[0], s.C[1], b, t0, t0, t1),
+			cubicBlossom(a, s.C[0], s.C[1], b, t0, t1, t1),
+		}}
+	case ArcSegment:
+		arc := s.Arc
+		arc.Start += s.Arc.Sweep * t0
+		arc.Sweep *= t1 - t0
+		return Segment{Kind: ArcSegment, Arc: arc}
+	}
+	return Segment{}
+}
+
+// reversed returns the segment drawn in the opposite direction.
+func (s Segment) reversed() Segment {
+	switch s.Kind {
+	case CubicSegment:
+		s.C[0], s.C[1] = s.C[1], s.C[0]
+	case ArcSegment:
+		s.Arc.Start += s.Arc.Sweep
+		s.Arc.Sweep = -s.Arc.Sweep
+	}
+	return s
+}
+
+// transform returns the segment transformed by the affine map f.
+func (s Segment) transform(f func(Vec2) Vec2) Segment {
+	switch s.Kind {
+	case QuadSegment:
+		s.C[0] = f(s.C[0])
+	case CubicSegment:
+		s.C[0], s.C[1] = f(s.C[0]), f(s.C[1])
+	case ArcSegment:
+		s.Arc = s.Arc.transform(f)
+	}
+	return s
+}
+
+// flatten approximates the segment from a to b by line segments,
+// and appends the vertices after a to vs. The last vertex is b.
+func (s *Segment) flatten(vs []Vec2, a, b Vec2, tol float64) []Vec2 {
+	switch s.Kind {
+	case QuadSegment:
+		p0, p1, p2, p3 := quadToCubic(a, s.C[0], b)
+		vs = flattenCubic(vs, tol, p0, p1, p2, p3)
+	case CubicSegment:
+		vs = flattenCubic(vs, tol, a, s.C[0], s.C[1], b)
+	case ArcSegment:
+		vs = s.Arc.flatten(vs, tol)
+	default:
+		return append(vs, b)
+	}
+	// Make sure the segment ends exactly at b.
+	vs[len(vs)-1] = b
+	return vs
+}
+
+// extremes appends to ts the positions along the segment (strictly
+// between 0 and 1) where it reaches a minimum or maximum x or y
+// coordinate.
+func (s *Segment) extremes(ts []float64, a, b Vec2) []float64 {
+	add := func(t float64) {
+		if t > 0 && t < 1 {
+			ts = append(ts, t)
+		}
+	}
+	for k := 0; k < 2; k++ {
+		switch s.Kind {
+		case QuadSegment:
+			if den := a[k] - 2*s.C[0][k] + b[k]; den != 0 {
+				add((a[k] - s.C[0][k]) / den)
+			}
+		case CubicSegment:
+			// The roots of the derivative, which is a quadratic.
+			d0, d1, d2 := s.C[0][k]-a[k], s.C[1][k]-s.C[0][k], b[k]-s.C[1][k]
+			qa, qb, qc := d0-2*d1+d2, 2*(d1-d0), d0
+			if qa == 0 {
+				if qb != 0 {
+					add(-qc / qb)
+				}
+				continue
+			}
+			disc := qb*qb - 4*qa*qc
+			if disc < 0 {
+				continue
+			}
+			sq := math.Sqrt(disc)
+			add((-qb + sq) / (2 * qa))
+			add((-qb - sq) / (2 * qa))
+		case ArcSegment:
+			// The coordinate is extreme where t is t0 plus a
+			// multiple of pi.
+			arc := &s.Arc
+			if arc.Sweep == 0 {
+				continue
+			}
+			t0 := math.Atan2(arc.V[k], arc.U[k])
+			lo := math.Min(arc.Start, arc.Start+arc.Sweep)
+			hi := math.Max(arc.Start, arc.Start+arc.Sweep)
+			for n := math.Ceil((lo - t0) / math.Pi); t0+n*math.Pi <= hi; n++ {
+				add((t0 + n*math.Pi - arc.Start) / arc.Sweep)
+			}
+		}
+	}
+	return ts
+}
+
+// segment returns the shape of the i'th segment of the path,
+// which joins p.V[i] to p.V[i+1].
+func (p *Path) segment(i int) Segment {
+	if p.S == nil {
+		return Segment{}
+	}
+	return p.S[i]
+}
+
+// curved reports whether any segments of the path are curves.
+func (p *Path) curved() bool {
+	for _, s := range p.S {
+		if s.Kind != LineSegment {
+			return true
+		}
+	}
+	return false
+}
+
+// add extends the path with the segment s, which goes to v.
+// If the path is empty, v is its first vertex.
+func (p *Path) add(v Vec2, s Segment) {
+	if len(p.V) == 0 {
+		p.V = append(p.V, v)
+		return
+	}
+	if s.Kind != LineSegment && p.S == nil {
+		p.S = make([]Segment, len(p.V)-1)
+	}
+	p.V = append(p.V, v)
+	if p.S != nil {
+		p.S = append(p.S, s)
+	}
+}
+
+// extend extends the path with the segments of q, which must
+// start where the path ends.
+func (p *Path) extend(q *Path) {
+	for i := 1; i < len(q.V); i++ {
+		p.add(q.V[i], q.segment(i-1))
+	}
+}
+
+// clone returns a copy of the path.
+func (p *Path) clone() Path {
+	c := Path{V: append([]Vec2(nil), p.V...), Closed: p.Closed, Attrs: p.Attrs}
+	if p.S != nil {
+		c.S = append([]Segment(nil), p.S...)
+	}
+	return c
+}
+
+// transform transforms the path in place by the affine map f.
+func (p *Path) transform(f func(Vec2) Vec2) {
+	for i, v := range p.V {
+		p.V[i] = f(v)
+	}
+	for i, s := range p.S {
+		p.S[i] = s.transform(f)
+	}
+}
+
+// Flatten replaces the curved segments of the path by line segments,
+// so that no point on a curve is further than tol from them.
+func (p *Path) Flatten(tol float64) {
+	p.flattenFunc(tol, nil)
+}
+
+func (p *Path) flattenFunc(tol float64, f func(s *Segment) bool) {
+	if !p.curved() {
+		p.S = nil
+		return
+	}
+	np := Path{V: []Vec2{p.V[0]}, Closed: p.Closed, Attrs: p.Attrs}
+	var vs []Vec2
+	for i, s := range p.S {
+		if s.Kind == LineSegment || (f != nil && !f(&s)) {
+			np.add(p.V[i+1], s)
+			continue
+		}
+		vs = s.flatten(vs[:0], p.V[i], p.V[i+1], tol)
+		for _, v := range vs {
+			np.add(v, Segment{})
+		}
+	}
+	*p = np
+}
+
+// Flatten replaces curves by line segments, so that no point on a
+// curve is further than tol from them. Curves are normally kept
+// until the paths are output, so that they can be drawn smoothly
+// at any size.
+func (ps *Paths) Flatten(tol float64) {
+	ps.FlattenFunc(tol, nil)
+}
+
+// FlattenFunc is like Flatten, but only flattens the curved
+// segments s for which f(s) is true.
+func (ps *Paths) FlattenFunc(tol float64, f func(s *Segment) bool) {
+	for i := range ps.P {
+		ps.P[i].flattenFunc(tol, f)
+	}
+}
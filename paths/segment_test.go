@@ -0,0 +1,141 @@
+package paths
+
+import (
+	"math"
+	"testing"
+)
+
+// samplePath returns points along each segment of the path, so
+// that paths with differently parameterized curves can be compared.
+func samplePath(p *Path) []Vec2 {
+	var vs []Vec2
+	for i := 1; i < len(p.V); i++ {
+		s := p.segment(i - 1)
+		for _, t := range []float64{0, 0.25, 0.5, 0.75} {
+			vs = append(vs, s.point(p.V[i-1], p.V[i], t))
+		}
+	}
+	return append(vs, p.V[len(p.V)-1])
+}
+
+// shapesNear reports whether the paths have the same shape, to
+// within eps.
+func shapesNear(a, b []Path, eps float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		va, vb := samplePath(&a[i]), samplePath(&b[i])
+		if len(va) != len(vb) {
+			return false
+		}
+		for j := range va {
+			if va[j].Dist(vb[j]) > eps {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// curvesNear reports whether each of the paths a is within eps of
+// the corresponding path of b, and vice versa, even if they're
+// made of different segments.
+func curvesNear(a, b []Path, eps float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	// within reports whether the points of p are all near q.
+	within := func(p, q Path) bool {
+		q = q.clone()
+		q.Flatten(eps / 10)
+		for _, v := range samplePath(&p) {
+			best := math.Inf(1)
+			for i := 1; i < len(q.V); i++ {
+				best = math.Min(best, segmentDist(v, q.V[i-1], q.V[i]))
+			}
+			if best > eps {
+				return false
+			}
+		}
+		return true
+	}
+	for i := range a {
+		if !within(a[i], b[i]) || !within(b[i], a[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+var testSegments = []struct {
+	desc string
+	a, b Vec2
+	s    Segment
+}{
+	{"line", Vec2{0, 0}, Vec2{10, 5}, Segment{}},
+	{"quad", Vec2{0, 0}, Vec2{10, 0}, Segment{Kind: QuadSegment, C: [2]Vec2{{5, 10}}}},
+	{"cubic", Vec2{0, 0}, Vec2{30, 0}, Segment{Kind: CubicSegment, C: [2]Vec2{{60, 40}, {-20, 40}}}},
+	{"arc", Vec2{10, 0}, Vec2{0, 20}, Segment{Kind: ArcSegment, Arc: Arc{U: Vec2{10, 0}, V: Vec2{0, 20}, Sweep: math.Pi / 2}}},
+	{"backwards arc", Vec2{10, 0}, Vec2{-10, 0}, Segment{Kind: ArcSegment, Arc: Arc{U: Vec2{10, 0}, V: Vec2{0, 10}, Sweep: -math.Pi}}},
+}
+
+func TestSegmentSplit(t *testing.T) {
+	for _, c := range testSegments {
+		for _, r := range [][2]float64{{0, 1}, {0, 0.3}, {0.3, 0.8}, {0.8, 1}} {
+			sub := c.s.split(c.a, c.b, r[0], r[1])
+			a, b := c.s.point(c.a, c.b, r[0]), c.s.point(c.a, c.b, r[1])
+			for _, u := range []float64{0, 0.2, 0.5, 0.9, 1} {
+				got := sub.point(a, b, u)
+				want := c.s.point(c.a, c.b, r[0]+(r[1]-r[0])*u)
+				if got.Dist(want) > 1e-9 {
+					t.Errorf("%s: split(%v) at %v = %v, want %v", c.desc, r, u, got, want)
+				}
+			}
+		}
+		rev := c.s.reversed()
+		for _, u := range []float64{0, 0.2, 0.5, 0.9, 1} {
+			got, want := rev.point(c.b, c.a, u), c.s.point(c.a, c.b, 1-u)
+			if got.Dist(want) > 1e-9 {
+				t.Errorf("%s: reversed at %v = %v, want %v", c.desc, u, got, want)
+			}
+		}
+	}
+}
+
+func TestTightenBoundsCurves(t *testing.T) {
+	for _, c := range testSegments {
+		ps := &Paths{P: []Path{{V: []Vec2{c.a, c.b}, S: []Segment{c.s}}}}
+		ps.TightenBounds()
+		// The bounds of a finely flattened copy are nearly the same.
+		flat := &Paths{P: []Path{ps.P[0].clone()}}
+		flat.Flatten(1e-6)
+		flat.TightenBounds()
+		if !shapesNear([]Path{{V: []Vec2{ps.Bounds.Min, ps.Bounds.Max}}}, []Path{{V: []Vec2{flat.Bounds.Min, flat.Bounds.Max}}}, 1e-5) {
+			t.Errorf("%s: bounds are %v, want %v", c.desc, ps.Bounds, flat.Bounds)
+		}
+	}
+}
+
+func TestFlattenPath(t *testing.T) {
+	p := Path{V: []Vec2{{0, 0}, {10, 0}, {20, 0}}, S: []Segment{{}, {Kind: QuadSegment, C: [2]Vec2{{15, 10}}}}}
+	ps := &Paths{P: []Path{p.clone()}}
+	ps.Flatten(0.01)
+	got := ps.P[0]
+	if got.S != nil {
+		t.Errorf("flattened path has segments %v", got.S)
+	}
+	if got.V[0] != p.V[0] || got.V[1] != p.V[1] || got.V[len(got.V)-1] != p.V[2] {
+		t.Errorf("flattened path %v doesn't keep the vertices of %v", got.V, p.V)
+	}
+	if len(got.V) < 8 {
+		t.Errorf("flattened curve has only %d vertices", len(got.V))
+	}
+
+	// FlattenFunc leaves segments that f rejects.
+	ps = &Paths{P: []Path{p.clone()}}
+	ps.FlattenFunc(0.01, func(s *Segment) bool { return s.Kind != QuadSegment })
+	if !shapesNear(ps.P, []Path{p}, 0) {
+		t.Errorf("FlattenFunc changed path to %v", ps.P)
+	}
+}
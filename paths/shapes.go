@@ -0,0 +1,201 @@
+package paths
+
+import "math"
+
+// The shapes here are made of lines and elliptical arcs, which are
+// kept as curves until the paths are flattened for output. Spirals
+// aren't arcs, so they're made of line segments that stay within
+// a given tolerance of the curve.
+//
+// Angles are in radians. Positive angles turn the x axis towards
+// the y axis, which is clockwise when y points down.
+
+// EllipseArc returns the part of an ellipse centered on c with
+// radii rx and ry, whose x axis is rotated by phi, that goes from
+// angle start to start+sweep. The angles are measured on the
+// ellipse before it's rotated, so the arc starts at
+// c + (rx cos(start), ry sin(start)) rotated by phi about c.
+func EllipseArc(c Vec2, rx, ry, phi, start, sweep float64) Path {
+	if sweep == 0 {
+		return Path{}
+	}
+	cp, sp := math.Cos(phi), math.Sin(phi)
+	a := Arc{
+		Center: c,
+		U:      Vec2{rx * cp, rx * sp},
+		V:      Vec2{-ry * sp, ry * cp},
+		Start:  start,
+		Sweep:  sweep,
+	}
+	p := Path{V: []Vec2{a.point(start)}}
+	// Arcs of more than half a turn are split, so that no segment
+	// starts and ends at the same point.
+	n := int(math.Ceil(math.Abs(sweep) / math.Pi))
+	for i := 0; i < n; i++ {
+		s := a
+		s.Start = start + sweep*float64(i)/float64(n)
+		s.Sweep = sweep / float64(n)
+		p.add(a.point(s.Start+s.Sweep), Segment{Kind: ArcSegment, Arc: s})
+	}
+	if math.Abs(sweep) == 2*math.Pi {
+		// A whole ellipse ends exactly where it starts.
+		p.V[len(p.V)-1] = p.V[0]
+		p.Closed = true
+	}
+	return p
+}
+
+// CircleArc returns the part of the circle centered on c with
+// radius r from angle start to start+sweep.
+func CircleArc(c Vec2, r, start, sweep float64) Path {
+	return EllipseArc(c, r, r, 0, start, sweep)
+}
+
+// Ellipse returns the ellipse centered on c with radii rx and ry,
+// whose x axis is rotated by phi. It starts and ends at the end of
+// the rotated x axis.
+func Ellipse(c Vec2, rx, ry, phi float64) Path {
+	return EllipseArc(c, rx, ry, phi, 0, 2*math.Pi)
+}
+
+// Circle returns the circle centered on c with radius r. It starts
+// and ends at its rightmost point.
+func Circle(c Vec2, r float64) Path {
+	return Ellipse(c, r, r, 0)
+}
+
+// Polygon returns the regular polygon with n sides centered on c,
+// whose vertices are a distance r from c. The first vertex is at
+// angle rot. There's no polygon with fewer than 3 sides.
+func Polygon(c Vec2, n int, r, rot float64) Path {
+	if n < 3 {
+		return Path{}
+	}
+	return star(c, n, r, r, rot, 1)
+}
+
+// Star returns the star with n points centered on c. The points
+// are a distance outer from c, and the corners between them a
+// distance inner. The first point is at angle rot.
+func Star(c Vec2, n int, outer, inner, rot float64) Path {
+	if n < 2 {
+		return Path{}
+	}
+	return star(c, n, outer, inner, rot, 2)
+}
+
+// star returns the closed path through k*n vertices evenly spaced
+// around c, alternately at distance r0 and r1.
+func star(c Vec2, n int, r0, r1, rot float64, k int) Path {
+	p := Path{Closed: true}
+	for i := 0; i <= k*n; i++ {
+		r := r0
+		if i%2 == 1 {
+			r = r1
+		}
+		theta := rot + 2*math.Pi*float64(i)/float64(k*n)
+		p.V = append(p.V, Vec2{c[0] + r*math.Cos(theta), c[1] + r*math.Sin(theta)})
+	}
+	// Make sure the path ends exactly where it starts.
+	p.V[len(p.V)-1] = p.V[0]
+	p.Closed = true
+	return p
+}
+
+// RoundedRect returns the outline of the rectangle b, with corners
+// rounded by elliptical arcs with radii rx and ry. The radii are
+// limited to half the width and height of the rectangle, and if
+// either is zero, the corners are square. It starts at the top
+// left, after the corner, and goes towards the right.
+func RoundedRect(b Bounds, rx, ry float64) Path {
+	x, y := b.Min[0], b.Min[1]
+	w, h := b.Max[0]-x, b.Max[1]-y
+	rx = math.Max(0, math.Min(rx, w/2))
+	ry = math.Max(0, math.Min(ry, h/2))
+	if rx == 0 || ry == 0 {
+		return Path{V: []Vec2{{x, y}, {x + w, y}, {x + w, y + h}, {x, y + h}, {x, y}}, Closed: true}
+	}
+	p := Path{Closed: true}
+	p.add(Vec2{x + rx, y}, Segment{})
+	corner := func(from, to Vec2) {
+		p.add(from, Segment{})
+		p.add(to, arcSegment(Identity(), from, rx, ry, 0, false, true, to))
+	}
+	corner(Vec2{x + w - rx, y}, Vec2{x + w, y + ry})
+	corner(Vec2{x + w, y + h - ry}, Vec2{x + w - rx, y + h})
+	corner(Vec2{x + rx, y + h}, Vec2{x, y + h - ry})
+	corner(Vec2{x, y + ry}, Vec2{x + rx, y})
+	return p
+}
+
+// ArchimedeanSpiral returns the spiral centered on c whose distance
+// from c grows steadily from r0 to r1 over the given number of
+// turns, starting at angle 0. Negative turns go the other way.
+// No point on the spiral is further than tol from the returned
+// line segments.
+func ArchimedeanSpiral(c Vec2, r0, r1, turns, tol float64) Path {
+	theta1 := 2 * math.Pi * math.Abs(turns)
+	a := 0.0
+	if theta1 > 0 {
+		a = (r1 - r0) / theta1
+	}
+	r := func(theta float64) float64 { return r0 + a*theta }
+	// The curve's second derivative with respect to theta has
+	// length sqrt(r^2 + 4a^2).
+	acc := func(r float64) float64 { return math.Hypot(r, 2*a) }
+	return spiral(c, r, acc, theta1, math.Copysign(1, turns), tol)
+}
+
+// LogSpiral returns the logarithmic spiral centered on c whose
+// distance from c grows by the same factor on every turn, from r0
+// to r1 over the given number of turns, starting at angle 0.
+// Negative turns go the other way. r0 and r1 must be positive. No
+// point on the spiral is further than tol from the returned line
+// segments.
+func LogSpiral(c Vec2, r0, r1, turns, tol float64) Path {
+	if r0 <= 0 || r1 <= 0 {
+		return Path{}
+	}
+	theta1 := 2 * math.Pi * math.Abs(turns)
+	k := 0.0
+	if theta1 > 0 {
+		k = math.Log(r1/r0) / theta1
+	}
+	r := func(theta float64) float64 { return r0 * math.Exp(k*theta) }
+	// The curve's second derivative with respect to theta has
+	// length r(1 + k^2).
+	acc := func(r float64) float64 { return r * (1 + k*k) }
+	return spiral(c, r, acc, theta1, math.Copysign(1, turns), tol)
+}
+
+// spiral samples the curve at distance r(theta) from c, at angle
+// dir*theta, for theta from 0 to theta1. r must be monotonic, and
+// acc(r) is the length of the curve's second derivative where it's
+// at distance r from c, which must grow with r.
+func spiral(c Vec2, r func(float64) float64, acc func(float64) float64, theta1, dir, tol float64) Path {
+	if tol <= 0 {
+		tol = curveTolerance
+	}
+	pt := func(theta float64) Vec2 {
+		rt := r(theta)
+		return Vec2{c[0] + rt*math.Cos(dir*theta), c[1] + rt*math.Sin(dir*theta)}
+	}
+	// A step of h in theta strays at most h^2 m / 8 from the chord,
+	// where m is the largest length of the second derivative on the
+	// step, which is at one of its ends.
+	step := func(m float64) float64 {
+		if m <= 0 {
+			return math.Pi / 2
+		}
+		return math.Min(math.Pi/2, math.Sqrt(8*tol/m))
+	}
+	p := Path{V: []Vec2{pt(0)}}
+	for theta := 0.0; theta < theta1; {
+		m := acc(math.Abs(r(theta)))
+		h := step(m)
+		h = step(math.Max(m, acc(math.Abs(r(math.Min(theta1, theta+h))))))
+		theta = math.Min(theta1, theta+h)
+		p.V = append(p.V, pt(theta))
+	}
+	return p
+}
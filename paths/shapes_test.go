@@ -0,0 +1,117 @@
+package paths
+
+import (
+	"math"
+	"testing"
+)
+
+// radii returns the smallest and largest distances from c of the
+// points of the path, with curves finely flattened.
+func radii(p Path, c Vec2) (float64, float64) {
+	p = p.clone()
+	p.Flatten(1e-6)
+	lo, hi := math.Inf(1), 0.0
+	for i, v := range p.V {
+		hi = math.Max(hi, v.Dist(c))
+		if i > 0 {
+			lo = math.Min(lo, segmentDist(c, p.V[i-1], v))
+		}
+	}
+	return lo, hi
+}
+
+func TestShapes(t *testing.T) {
+	c := Vec2{10, 20}
+	cases := []struct {
+		desc     string
+		p        Path
+		segments int
+		lo, hi   float64 // the range of distances from c
+	}{
+		{"circle", Circle(c, 5), 2, 5, 5},
+		{"ellipse", Ellipse(c, 8, 3, 0.4), 2, 3, 8},
+		{"circle arc", CircleArc(c, 5, 1, 4), 2, 5, 5},
+		{"small arc", CircleArc(c, 5, 1, -1), 1, 5, 5},
+		{"triangle", Polygon(c, 3, 6, 0.2), 3, 3, 6},
+		{"hexagon", Polygon(c, 6, 6, 0), 6, 6 * math.Sqrt(3) / 2, 6},
+		{"star", Star(c, 5, 10, 4, 0), 10, 4, 10},
+	}
+	for _, tc := range cases {
+		if got := len(tc.p.V) - 1; got != tc.segments {
+			t.Errorf("%s: got %d segments, want %d", tc.desc, got, tc.segments)
+		}
+		lo, hi := radii(tc.p, c)
+		if math.Abs(lo-tc.lo) > 1e-5 || math.Abs(hi-tc.hi) > 1e-5 {
+			t.Errorf("%s: distance from center is from %g to %g, want %g to %g", tc.desc, lo, hi, tc.lo, tc.hi)
+		}
+	}
+
+	// Closed shapes end exactly where they start.
+	for _, p := range []Path{Circle(c, 5), Ellipse(c, 8, 3, 0.4), Polygon(c, 7, 3, 1), Star(c, 4, 5, 1, 0), RoundedRect(Bounds{Max: Vec2{10, 5}}, 1, 2)} {
+		if !p.Closed || p.V[0] != p.V[len(p.V)-1] {
+			t.Errorf("closed shape %v ends at %v, not at its start %v", p, p.V[len(p.V)-1], p.V[0])
+		}
+	}
+	if p := CircleArc(c, 5, 0, 1); p.Closed {
+		t.Errorf("arc %v is closed", p)
+	}
+
+	if p := Polygon(c, 2, 5, 0); len(p.V) != 0 {
+		t.Errorf("polygon with 2 sides is %v, want empty", p)
+	}
+}
+
+func TestRoundedRect(t *testing.T) {
+	b := Bounds{Min: Vec2{10, 20}, Max: Vec2{50, 40}}
+	for _, r := range [][2]float64{{0, 0}, {5, 5}, {5, 2}, {100, 100}} {
+		ps := &Paths{P: []Path{RoundedRect(b, r[0], r[1])}}
+		ps.TightenBounds()
+		if ps.Bounds.Min.Dist(b.Min) > 1e-9 || ps.Bounds.Max.Dist(b.Max) > 1e-9 {
+			t.Errorf("rounded rect with radii %v has bounds %v, want %v", r, ps.Bounds, b)
+		}
+	}
+	// With large radii, the rectangle is an ellipse.
+	lo, hi := radii(RoundedRect(b, 100, 100), b.Center())
+	if math.Abs(lo-10) > 1e-5 || math.Abs(hi-20) > 1e-5 {
+		t.Errorf("rounded rect with large radii is %g to %g from its center, want 10 to 20", lo, hi)
+	}
+}
+
+func TestSpirals(t *testing.T) {
+	c := Vec2{5, 5}
+	cases := []struct {
+		desc        string
+		p           Path
+		r           func(theta float64) float64
+		turns       float64
+		r0, r1, tol float64
+	}{
+		{"archimedean", ArchimedeanSpiral(c, 0, 50, 5, 0.05), func(th float64) float64 { return 50 * th / (10 * math.Pi) }, 5, 0, 50, 0.05},
+		{"archimedean inwards", ArchimedeanSpiral(c, 30, 10, -2, 0.1), func(th float64) float64 { return 30 - 20*th/(4*math.Pi) }, -2, 30, 10, 0.1},
+		{"log", LogSpiral(c, 1, 100, 3, 0.01), func(th float64) float64 { return math.Pow(100, th/(6*math.Pi)) }, 3, 1, 100, 0.01},
+	}
+	for _, tc := range cases {
+		theta1 := 2 * math.Pi * math.Abs(tc.turns)
+		dir := math.Copysign(1, tc.turns)
+		f := func(u float64) Vec2 {
+			th := u * theta1
+			r := tc.r(th)
+			return Vec2{c[0] + r*math.Cos(dir*th), c[1] + r*math.Sin(dir*th)}
+		}
+		vs := tc.p.V
+		if vs[0].Dist(f(0)) > 1e-9 || vs[len(vs)-1].Dist(f(1)) > 1e-9 {
+			t.Errorf("%s: spiral goes from %v to %v, want %v to %v", tc.desc, vs[0], vs[len(vs)-1], f(0), f(1))
+		}
+		if got := flatError(f, vs); got > tc.tol {
+			t.Errorf("%s: spiral is %g from its segments, want at most %g", tc.desc, got, tc.tol)
+		}
+		// A spiral with a finer tolerance has more segments.
+		finer := ArchimedeanSpiral(c, tc.r0, tc.r1, tc.turns, tc.tol/4)
+		if tc.desc == "log" {
+			finer = LogSpiral(c, tc.r0, tc.r1, tc.turns, tc.tol/4)
+		}
+		if len(finer.V) <= len(vs) {
+			t.Errorf("%s: tolerance %g gave %d vertices, but %g gave %d", tc.desc, tc.tol/4, len(finer.V), tc.tol, len(vs))
+		}
+	}
+}
@@ -5,8 +5,8 @@ import (
 )
 
 func vec2linedist(v, s, e Vec2) float64 {
-	ds := vec2dist(v, s)
-	de := vec2dist(v, e)
+	ds := v.Dist(s)
+	de := v.Dist(e)
 	diff := Vec2{e[0] - s[0], e[1] - s[1]}
 	dlen := math.Sqrt(diff[0]*diff[0] + diff[1]*diff[1])
 	if dlen == 0 {
@@ -16,32 +16,116 @@ func vec2linedist(v, s, e Vec2) float64 {
 	return math.Min(math.Min(dp, ds), de)
 }
 
-func simplifyPath(v []Vec2, tol float64) []Vec2 {
-	worst := 0
+// simplifyIndex appends to is the indexes of the vertices of v
+// between lo (exclusive) and hi (inclusive) that are kept when
+// the path from v[lo] to v[hi] is simplified, using the
+// Douglas-Peucker algorithm.
+func simplifyIndex(is []int, v []Vec2, lo, hi int, tol float64) []int {
+	worst := lo
 	worstD := 0.0
-	for i := 1; i < len(v)-1; i++ {
-		d := vec2linedist(v[i], v[0], v[len(v)-1])
+	for i := lo + 1; i < hi; i++ {
+		d := vec2linedist(v[i], v[lo], v[hi])
 		if d > worstD {
 			worst = i
 			worstD = d
 		}
 	}
-	if worstD <= tol {
-		return []Vec2{v[0], v[len(v)-1]}
+	if worstD <= tol || worst == lo {
+		return append(is, hi)
 	}
-	if worst <= 0 || worst >= len(v)-1 {
-		panic("simply the worst")
+	is = simplifyIndex(is, v, lo, worst, tol)
+	return simplifyIndex(is, v, worst, hi, tol)
+}
+
+func simplifyPath(v []Vec2, tol float64) []Vec2 {
+	var r []Vec2
+	for _, i := range simplifyIndex([]int{0}, v, 0, len(v)-1, tol) {
+		r = append(r, v[i])
 	}
-	lefts := simplifyPath(v[:worst+1], tol)
-	rights := simplifyPath(v[worst:], tol)
-	return append(lefts, rights[1:]...)
+	return r
+}
+
+// simplifyLoop is like simplifyPath, but v is a loop whose last
+// vertex is the same as its first. The loop isn't flattened into
+// a line: if it has them, at least three vertices are kept. The
+// vertex at the seam is removed too if it's not needed, and the
+// loop then starts from the next vertex.
+func simplifyLoop(v []Vec2, tol float64) []Vec2 {
+	is := simplifyIndex([]int{0}, v, 0, len(v)-1, tol)
+	if len(is) == 3 {
+		// Keep the vertex furthest from the line the loop has
+		// been flattened to, and simplify either side of it again.
+		mid, end := is[1], is[2]
+		worst, worstD := 0, 0.0
+		for i := 1; i < end; i++ {
+			if d := vec2linedist(v[i], v[0], v[mid]); d > worstD {
+				worst, worstD = i, d
+			}
+		}
+		if worst > mid {
+			is = simplifyIndex([]int{0, mid}, v, mid, worst, tol)
+			is = simplifyIndex(is, v, worst, end, tol)
+		} else if worst > 0 {
+			is = simplifyIndex([]int{0}, v, 0, worst, tol)
+			is = simplifyIndex(is, v, worst, mid, tol)
+			is = append(is, end)
+		}
+	}
+	n := len(is)
+	// The seam can go if the vertices either side of it, back to
+	// the neighbouring kept vertices, are all near the line
+	// between those neighbours. At least a triangle is kept.
+	removable := n > 4
+	a, b := v[is[n-2]], v[is[1]]
+	for i := is[n-2] + 1; removable && i < len(v)-1+is[1]; i++ {
+		removable = vec2linedist(v[i%(len(v)-1)], a, b) <= tol
+	}
+	if removable {
+		is = append(is[1:n-1], is[1])
+	}
+	var r []Vec2
+	for _, i := range is {
+		r = append(r, v[i])
+	}
+	return r
 }
 
 // Simplify removes points from paths, with the guarantee that
 // all removed points are within the given tolerance (distance)
-// from the new path.
+// from the new path. Only the points between straight line
+// segments are removed; curves are left as they are. Closed
+// paths stay closed, but may start from a different vertex if
+// the one at their seam isn't needed.
 func (ps *Paths) Simplify(tol float64) {
 	for i, p := range ps.P {
-		ps.P[i].V = simplifyPath(p.V, tol)
+		if !p.curved() {
+			if p.Closed {
+				ps.P[i] = Path{V: simplifyLoop(p.V, tol), Closed: true, Attrs: p.Attrs}
+			} else {
+				ps.P[i] = Path{V: simplifyPath(p.V, tol), Attrs: p.Attrs}
+			}
+			continue
+		}
+		np := Path{V: p.V[:1:1], Closed: p.Closed, Attrs: p.Attrs}
+		// start is the first vertex of the current run of lines.
+		start := 0
+		for j, s := range p.S {
+			if s.Kind == LineSegment {
+				continue
+			}
+			if j > start {
+				for _, v := range simplifyPath(p.V[start:j+1], tol)[1:] {
+					np.add(v, Segment{})
+				}
+			}
+			np.add(p.V[j+1], s)
+			start = j + 1
+		}
+		if end := len(p.V) - 1; end > start {
+			for _, v := range simplifyPath(p.V[start:], tol)[1:] {
+				np.add(v, Segment{})
+			}
+		}
+		ps.P[i] = np
 	}
 }
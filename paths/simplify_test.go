@@ -1,6 +1,7 @@
 package paths
 
 import (
+	"math"
 	"reflect"
 	"testing"
 )
@@ -59,3 +60,51 @@ func TestSimplify(t *testing.T) {
 		}
 	}
 }
+
+func TestSimplifyCurves(t *testing.T) {
+	arc := Segment{Kind: ArcSegment, Arc: Arc{Center: Vec2{2, 1}, U: Vec2{0, -1}, V: Vec2{1, 0}, Sweep: math.Pi}}
+	p := Path{
+		V: []Vec2{{0, 0}, {1, 0.01}, {2, 0}, {2, 2}, {1, 2.01}, {0, 2}},
+		S: []Segment{{}, {}, arc, {}, {}},
+	}
+	ps := &Paths{P: []Path{p.clone()}}
+	ps.Simplify(0.1)
+	want := []Path{{
+		V: []Vec2{{0, 0}, {2, 0}, {2, 2}, {0, 2}},
+		S: []Segment{{}, arc, {}},
+	}}
+	if !reflect.DeepEqual(ps.P, want) {
+		t.Errorf("Simplify(0.1) = %v, want %v", ps.P, want)
+	}
+}
+
+func TestSimplifyClosed(t *testing.T) {
+	cases := []struct {
+		desc string
+		path Path
+		want Path
+	}{
+		{
+			desc: "seam in the middle of an edge",
+			path: Path{V: []Vec2{{5, 0}, {10, 0}, {10, 10}, {0, 10}, {0, 0}, {5, 0}}, Closed: true},
+			want: Path{V: []Vec2{{10, 0}, {10, 10}, {0, 10}, {0, 0}, {10, 0}}, Closed: true},
+		},
+		{
+			desc: "seam at a corner",
+			path: Path{V: []Vec2{{0, 0}, {5, 0}, {10, 0}, {10, 10}, {0, 10}, {0, 0}}, Closed: true},
+			want: Path{V: []Vec2{{0, 0}, {10, 0}, {10, 10}, {0, 10}, {0, 0}}, Closed: true},
+		},
+		{
+			desc: "flat triangle",
+			path: Path{V: []Vec2{{0, 0}, {10, 0}, {5, 0.1}, {0, 0}}, Closed: true},
+			want: Path{V: []Vec2{{0, 0}, {10, 0}, {5, 0.1}, {0, 0}}, Closed: true},
+		},
+	}
+	for _, c := range cases {
+		ps := &Paths{P: []Path{c.path.clone()}}
+		ps.Simplify(0.5)
+		if !reflect.DeepEqual(ps.P, []Path{c.want}) {
+			t.Errorf("%s: Simplify(0.5) = %v, want %v", c.desc, ps.P, c.want)
+		}
+	}
+}
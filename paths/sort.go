@@ -52,9 +52,10 @@ type vindexLeaf struct {
 // of valid verticles in the kd-tree. This isn't best, but it's easy
 // and fast enough so far.
 type vindex struct {
-	minR float64
-	m    map[verticle]struct{}
-	node interface{}
+	minR  float64
+	m     map[verticle]struct{}
+	loops map[int][]verticle // the verticles of each loop, which are removed together
+	node  interface{}
 }
 
 const leafThreshold = 20
@@ -67,7 +68,7 @@ func buildIndex(ps *Paths, vs []verticle, yaxis bool) interface{} {
 		var rx []Vec2
 		var rvs []verticle
 		for _, v := range vs {
-			rx = append(rx, ps.P[v.path].V[v.start])
+			rx = append(rx, ps.vertex(v.path, v.start))
 			rvs = append(rvs, v)
 
 		}
@@ -76,8 +77,8 @@ func buildIndex(ps *Paths, vs []verticle, yaxis bool) interface{} {
 	// We need to find the median verticle. Sorting and picking the
 	// central element is not optimal, but is fast.
 	sort.Slice(vs, func(i, j int) bool {
-		vi := ps.P[vs[i].path].V[vs[i].start]
-		vj := ps.P[vs[j].path].V[vs[j].start]
+		vi := ps.vertex(vs[i].path, vs[i].start)
+		vj := ps.vertex(vs[j].path, vs[j].start)
 		if yaxis {
 			return vi[1] < vj[1]
 		} else {
@@ -87,7 +88,7 @@ func buildIndex(ps *Paths, vs []verticle, yaxis bool) interface{} {
 
 	k := len(vs) / 2
 	return &vindexNode{
-		x:     ps.P[vs[k].path].V[vs[k].start],
+		x:     ps.vertex(vs[k].path, vs[k].start),
 		v:     vs[k],
 		yaxis: yaxis,
 		left:  buildIndex(ps, vs[:k], !yaxis),
@@ -95,16 +96,17 @@ func buildIndex(ps *Paths, vs []verticle, yaxis bool) interface{} {
 	}
 }
 
-func indexVerticles(ps *Paths, vs []verticle, minR float64) *vindex {
+func indexVerticles(ps *Paths, vs []verticle, loops map[int][]verticle, minR float64) *vindex {
 	m := map[verticle]struct{}{}
 	for _, v := range vs {
 		m[v] = struct{}{}
 	}
 	node := buildIndex(ps, vs, false)
 	return &vindex{
-		minR: minR,
-		m:    m,
-		node: node,
+		minR:  minR,
+		m:     m,
+		loops: loops,
+		node:  node,
 	}
 }
 
@@ -115,12 +117,6 @@ type vcand struct {
 	v    verticle
 }
 
-func vec2dist(v0, v1 Vec2) float64 {
-	dx := v0[0] - v1[0]
-	dy := v0[1] - v1[1]
-	return math.Sqrt(dx*dx + dy*dy)
-}
-
 // vec2distbounds returns the distance of v0 from the
 // rectangle given by bounds. Points inside the bounds
 // return 0.
@@ -132,13 +128,13 @@ func vec2distbounds(v0 Vec2, b Bounds) float64 {
 		math.Min(math.Max(v0[0], b.Min[0]), b.Max[0]),
 		math.Min(math.Max(v0[1], b.Min[1]), b.Max[1]),
 	}
-	return vec2dist(v0, v)
+	return v0.Dist(v)
 }
 
 func (vi *vindex) findLeafRadius(vl *vindexLeaf, pos Vec2, r float64) []vcand {
 	var cand []vcand
 	for i := range vl.x {
-		d := vec2dist(vl.x[i], pos)
+		d := vl.x[i].Dist(pos)
 		if d <= r {
 			if _, ok := vi.m[vl.v[i]]; ok {
 				cand = append(cand, vcand{dist: d, v: vl.v[i]})
@@ -162,7 +158,7 @@ func (vi *vindex) findRadius(vni interface{}, pos Vec2, r float64, bounds Bounds
 		return nil
 	}
 	var cand []vcand
-	d := vec2dist(vn.x, pos)
+	d := vn.x.Dist(pos)
 	if d <= r {
 		if _, ok := vi.m[vn.v]; ok {
 			cand = append(cand, vcand{dist: d, v: vn.v})
@@ -232,62 +228,112 @@ func (vi *vindex) popNearest(pos Vec2) verticle {
 			v := cands[best].v
 			delete(vi.m, v)
 			delete(vi.m, v.reversed())
+			for _, lv := range vi.loops[v.path] {
+				delete(vi.m, lv)
+			}
 			return v
 		}
 		r *= 2
 	}
 }
 
-func sortVerticles(ps *Paths, vs []verticle, want int) []verticle {
+func sortVerticles(ps *Paths, vs []verticle, loops map[int][]verticle, want int) []verticle {
 	// This uses the same ideas as Invonvergent's edge sort.
 	// https://github.com/inconvergent/svgsort/blob/master/svgsort/sort_utils.py
 	// Start from the closest point to the origin, follow a line from
 	// that point, and then greedily pick the closest point to the endpoint
 	// of that line that hasn't already been consumed. Repeat.
 	minR := (ps.Bounds.Max[0] - ps.Bounds.Min[0]) / 100
-	idx := indexVerticles(ps, vs, minR)
+	idx := indexVerticles(ps, vs, loops, minR)
 	res := make([]verticle, 0, want)
 	var pos Vec2
 	for len(res) < want {
 		v := idx.popNearest(pos)
 		res = append(res, v)
-		pos = ps.P[v.path].V[v.end]
+		pos = ps.vertex(v.path, v.end)
 	}
 	return res
 }
 
+// vertex returns the i'th vertex of the k'th path. The vertices
+// of a closed path are numbered cyclically, so that a loop can be
+// drawn starting from any of them.
+func (ps *Paths) vertex(k, i int) Vec2 {
+	p := &ps.P[k]
+	if m := len(p.V) - 1; p.Closed && m > 0 {
+		return p.V[i%m]
+	}
+	return p.V[i]
+}
+
 // Sort reorders paths to reduce the amount of movement between the
 // end of one path and the start of the next. This is intended to
 // improve rendering time using a physical xy plotter.
-// The reordering can be configured in a limited way.
+// The reordering can be configured in a limited way. Unless paths
+// are split, a closed path may be drawn starting from any of its
+// vertices, and it stays closed. Paths are only joined together
+// if they have the same attributes. The paths for each pen are kept
+// together, so that the pen is only changed once, and the pens are
+// used in the order they first appear.
 func (ps *Paths) Sort(cfg *SortConfig) {
+	var pens []int
+	byPen := map[int][]Path{}
+	for _, p := range ps.P {
+		if _, ok := byPen[p.Attrs.Pen]; !ok {
+			pens = append(pens, p.Attrs.Pen)
+		}
+		byPen[p.Attrs.Pen] = append(byPen[p.Attrs.Pen], p)
+	}
+	if len(pens) > 1 {
+		var sorted []Path
+		for _, pen := range pens {
+			pps := &Paths{Bounds: ps.Bounds, P: byPen[pen]}
+			pps.Sort(cfg)
+			sorted = append(sorted, pps.P...)
+		}
+		ps.P = sorted
+		return
+	}
+
 	// Construct all the verticles.
 	// If we allow splitting, each line in a path gets
 	// its own verticle, otherwise the verticle contains
-	// only the start and endpoint.
+	// only the start and endpoint. A closed path has a
+	// verticle for each of its vertices, going all the
+	// way round the loop from that vertex.
 	// If we allow reversed lines, whenever we add a verticle
 	// we also add the reversed form of it.
 	var vs []verticle
+	loops := map[int][]verticle{}
+	n := 0 // the number of verticles to draw
 	for i, p := range ps.P {
+		m := len(p.V) - 1
 		if cfg.Split {
-			for j := 0; j < len(p.V)-1; j++ {
+			for j := 0; j < m; j++ {
 				vs = append(vs, verticle{i, j, j + 1})
 				if cfg.Reverse {
 					vs = append(vs, verticle{i, j + 1, j})
 				}
 			}
+			n += m
+		} else if p.Closed && m > 0 {
+			for j := 0; j < m; j++ {
+				loops[i] = append(loops[i], verticle{i, j, j + m})
+				if cfg.Reverse {
+					loops[i] = append(loops[i], verticle{i, j + m, j})
+				}
+			}
+			vs = append(vs, loops[i]...)
+			n++
 		} else {
-			vs = append(vs, verticle{i, 0, len(p.V) - 1})
+			vs = append(vs, verticle{i, 0, m})
 			if cfg.Reverse {
-				vs = append(vs, verticle{i, len(p.V) - 1, 0})
+				vs = append(vs, verticle{i, m, 0})
 			}
+			n++
 		}
 	}
-	n := len(vs)
-	if cfg.Reverse {
-		n /= 2
-	}
-	svs := sortVerticles(ps, vs, n)
+	svs := sortVerticles(ps, vs, loops, n)
 
 	np := &Paths{Bounds: ps.Bounds}
 	for _, v := range svs {
@@ -295,9 +341,25 @@ func (ps *Paths) Sort(cfg *SortConfig) {
 		if v.end < v.start {
 			d = -1
 		}
+		p := &ps.P[v.path]
+		m := len(p.V) - 1
+		_, loop := loops[v.path]
+		if loop {
+			// Loops are drawn as paths of their own, so that
+			// they stay closed.
+			np.P = append(np.P, Path{V: []Vec2{ps.vertex(v.path, v.start)}, Closed: true, Attrs: p.Attrs})
+		}
 		for i := v.start; i != v.end; i += d {
-			np.move(ps.P[v.path].V[i])
-			np.line(ps.P[v.path].V[i+d])
+			var s Segment
+			if d > 0 {
+				s = p.segment(i % m)
+			} else {
+				s = p.segment((i - 1) % m).reversed()
+			}
+			if !loop {
+				np.move(ps.vertex(v.path, i), p.Attrs)
+			}
+			np.segment(ps.vertex(v.path, i+d), s)
 		}
 	}
 	*ps = *np
@@ -3,6 +3,7 @@ package paths
 import (
 	"fmt"
 	"math/rand"
+	"reflect"
 	"testing"
 )
 
@@ -11,7 +12,7 @@ func moved(ps *Paths) float64 {
 	d := 0.0
 	var last Vec2
 	for _, p := range ps.P {
-		d += vec2dist(last, p.V[0])
+		d += last.Dist(p.V[0])
 		last = p.V[len(p.V)-1]
 	}
 	return d
@@ -63,3 +64,84 @@ func TestSort(t *testing.T) {
 		})
 	}
 }
+
+func TestSortCurves(t *testing.T) {
+	// The cubic is nearer the origin at its end, so it's drawn
+	// backwards.
+	cubic := Path{
+		V: []Vec2{{100, 100}, {10, 0}},
+		S: []Segment{{Kind: CubicSegment, C: [2]Vec2{{50, 100}, {10, 50}}}},
+	}
+	ps := &Paths{Bounds: Bounds{Max: Vec2{100, 100}}, P: []Path{cubic.clone()}}
+	ps.Sort(&SortConfig{Reverse: true})
+	want := Path{
+		V: []Vec2{{10, 0}, {100, 100}},
+		S: []Segment{{Kind: CubicSegment, C: [2]Vec2{{10, 50}, {50, 100}}}},
+	}
+	if !shapesNear(ps.P, []Path{want}, 0) {
+		t.Errorf("sorted paths are %v, want %v", ps.P, want)
+	}
+}
+
+func TestSortClosed(t *testing.T) {
+	// The loops are nearest the origin at (10, 10) and (10, 30),
+	// which aren't where they start.
+	square := Path{V: []Vec2{{20, 20}, {10, 20}, {10, 10}, {20, 10}, {20, 20}}, Closed: true}
+	tri := Path{V: []Vec2{{20, 30}, {10, 30}, {15, 40}, {20, 30}}, Closed: true}
+	for _, reverse := range []bool{false, true} {
+		ps := &Paths{Bounds: Bounds{Max: Vec2{50, 50}}, P: []Path{tri.clone(), square.clone()}}
+		ps.Sort(&SortConfig{Reverse: reverse})
+		if len(ps.P) != 2 {
+			t.Fatalf("reverse %v: sorted into %d paths %v, want 2", reverse, len(ps.P), ps.P)
+		}
+		for i, want := range []struct {
+			start Vec2
+			n     int
+		}{{Vec2{10, 10}, 5}, {Vec2{10, 30}, 4}} {
+			p := ps.P[i]
+			if !p.Closed || p.V[0] != want.start || p.V[len(p.V)-1] != want.start || len(p.V) != want.n {
+				t.Errorf("reverse %v: path %d is %v, want a closed loop of %d vertices from %v", reverse, i, p, want.n, want.start)
+			}
+		}
+	}
+}
+
+func TestSortAttrs(t *testing.T) {
+	// The lines join end to end, but only the first two have the
+	// same attributes, so only they are joined.
+	red, blue := Attrs{Color: "#ff0000"}, Attrs{Color: "#0000ff"}
+	ps := &Paths{
+		Bounds: Bounds{Max: Vec2{100, 100}},
+		P: []Path{
+			{V: []Vec2{{0, 0}, {10, 0}}, Attrs: red},
+			{V: []Vec2{{10, 0}, {20, 0}}, Attrs: red},
+			{V: []Vec2{{20, 0}, {30, 0}}, Attrs: blue},
+		},
+	}
+	ps.Sort(&SortConfig{Split: true})
+	want := []Path{
+		{V: []Vec2{{0, 0}, {10, 0}, {20, 0}}, Attrs: red},
+		{V: []Vec2{{20, 0}, {30, 0}}, Attrs: blue},
+	}
+	if !reflect.DeepEqual(ps.P, want) {
+		t.Errorf("sorted paths are %v, want %v", ps.P, want)
+	}
+}
+
+func TestSortPens(t *testing.T) {
+	// Lines for two pens are interleaved, but each pen's lines are
+	// drawn together, starting with the pen that's used first.
+	ps := &Paths{Bounds: Bounds{Max: Vec2{100, 100}}}
+	for i := 0; i < 10; i++ {
+		x := float64(i) * 10
+		ps.P = append(ps.P, Path{V: []Vec2{{x, 0}, {x, 10}}, Attrs: Attrs{Pen: 2 - i%2}})
+	}
+	ps.Sort(&SortConfig{Reverse: true})
+	var pens []int
+	for _, p := range ps.P {
+		pens = append(pens, p.Attrs.Pen)
+	}
+	if want := []int{2, 2, 2, 2, 2, 1, 1, 1, 1, 1}; !reflect.DeepEqual(pens, want) {
+		t.Errorf("sorted paths have pens %v, want %v", pens, want)
+	}
+}
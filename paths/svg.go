@@ -8,228 +8,152 @@ import (
 	"io"
 	"io/ioutil"
 	"math"
-	"os"
-	"strconv"
 	"strings"
-	"text/scanner"
-	"unicode"
 
 	"github.com/JoshVarga/svgparser"
 	"golang.org/x/net/html/charset"
 )
 
-var isSVGUnit = map[string]bool{
-	"mm": true,
-}
-
-func parseDist(s string) (float64, string, error) {
-	fp, up := s, ""
-	for i, c := range s {
-		if !unicode.IsDigit(c) && c != '.' && c != '-' && c != '+' {
-			fp, up = s[:i], s[i:]
-			break
-		}
-	}
-	f, err := strconv.ParseFloat(fp, 64)
+// parseViewBox parses a viewBox attribute, which is a list of
+// four numbers: min-x, min-y, width and height.
+func parseViewBox(s string) ([4]float64, error) {
+	var vb [4]float64
+	fs, err := parseNumberList(s)
 	if err != nil {
-		return 0, "", err
+		return vb, fmt.Errorf("bad viewBox: %v", err)
+	}
+	if len(fs) != 4 {
+		return vb, fmt.Errorf("viewBox should have 4 numbers: got %q", s)
 	}
-	if up != "" && !isSVGUnit[up] {
-		return 0, "", fmt.Errorf("%q is not understood by this program as an SVG unit", up)
+	copy(vb[:], fs)
+	if vb[2] <= 0 || vb[3] <= 0 {
+		return vb, fmt.Errorf("viewBox %q has non-positive width or height", s)
 	}
-	return f, up, nil
+	return vb, nil
 }
 
-func parseBounds(e *svgparser.Element) (Bounds, error) {
-	width, _, werr := parseDist(e.Attributes["width"])
-	height, _, herr := parseDist(e.Attributes["height"])
-	if werr != nil {
-		return Bounds{}, werr
+// viewBoxXform returns the transform that maps the view box vb onto
+// a viewport of size w by h (whose top-left corner is the origin),
+// as described by the preserveAspectRatio attribute par.
+func viewBoxXform(vb [4]float64, par string, w, h float64) (*Matrix, error) {
+	fields := strings.Fields(par)
+	if len(fields) > 0 && fields[0] == "defer" {
+		fields = fields[1:]
 	}
-	if herr != nil {
-		return Bounds{}, herr
+	align, slice := "xMidYMid", false
+	if len(fields) > 0 {
+		align = fields[0]
 	}
-	// TODO: parse view box
-	return Bounds{
-		Max: Vec2{float64(width), float64(height)},
-	}, nil
-}
-
-func parseLine(ps *Paths, xform *svgXform, e *svgparser.Element) error {
-	var ferr error
-	pf := func(s string) float64 {
-		if ferr != nil {
-			return 0
+	if len(fields) > 1 {
+		switch fields[1] {
+		case "meet":
+		case "slice":
+			slice = true
+		default:
+			return nil, fmt.Errorf("bad preserveAspectRatio %q: expected meet or slice, got %q", par, fields[1])
 		}
-		f, err := strconv.ParseFloat(s, 64)
-		ferr = err
-		return f
 	}
-	x1 := pf(e.Attributes["x1"])
-	x2 := pf(e.Attributes["x2"])
-	y1 := pf(e.Attributes["y1"])
-	y2 := pf(e.Attributes["y2"])
-	ps.move(xform.Apply(Vec2{x1, y1}))
-	ps.line(xform.Apply(Vec2{x2, y2}))
-	return ferr
-}
-
-type xformScannerState int
-
-const (
-	xfsName xformScannerState = 1 + iota
-	xfsBra
-	xfsMaybeComma
-	xfsArg
-)
-
-func parseFloats(a []string) ([]float64, error) {
-	var r []float64
-	for _, x := range a {
-		f, err := strconv.ParseFloat(x, 64)
-		if err != nil {
-			return nil, err
-		}
-		r = append(r, f)
+	if len(fields) > 2 {
+		return nil, fmt.Errorf("bad preserveAspectRatio %q", par)
 	}
-	return r, nil
-}
-
-func svgXformTranslate(x, y float64) *svgXform {
-	return &svgXform{
-		M: [3][3]float64{
-			{1, 0, x},
-			{0, 1, y},
-			{0, 0, 1},
-		},
+	sx, sy := w/vb[2], h/vb[3]
+	if align == "none" {
+		return Scale(sx, sy).Compose(Translate(Vec2{-vb[0], -vb[1]})), nil
 	}
-}
-
-func svgXformScale(x, y float64) *svgXform {
-	return &svgXform{
-		M: [3][3]float64{
-			{x, 0, 0},
-			{0, y, 0},
-			{0, 0, 1},
-		},
+	if len(align) != 8 || align[0] != 'x' || align[4] != 'Y' {
+		return nil, fmt.Errorf("bad preserveAspectRatio %q: unknown alignment %q", par, align)
 	}
-}
-
-func svgXformRotate(theta float64) *svgXform {
-	c, s := math.Cos(theta), math.Sin(theta)
-	return &svgXform{
-		M: [3][3]float64{
-			{c, s, 0},
-			{-s, c, 0},
-			{0, 0, 1},
-		},
+	s := math.Min(sx, sy)
+	if slice {
+		s = math.Max(sx, sy)
+	}
+	// alignFrac says where in the spare space the view box is placed.
+	alignFrac := func(a string) (float64, error) {
+		switch a {
+		case "Min":
+			return 0, nil
+		case "Mid":
+			return 0.5, nil
+		case "Max":
+			return 1, nil
+		}
+		return 0, fmt.Errorf("bad preserveAspectRatio %q: unknown alignment %q", par, align)
+	}
+	ax, err := alignFrac(align[1:4])
+	if err != nil {
+		return nil, err
 	}
+	ay, err := alignFrac(align[5:8])
+	if err != nil {
+		return nil, err
+	}
+	tx := (w - vb[2]*s) * ax
+	ty := (h - vb[3]*s) * ay
+	return Translate(Vec2{tx, ty}).Compose(Scale(s, s)).Compose(Translate(Vec2{-vb[0], -vb[1]})), nil
 }
 
-func parseSingleXform(name string, args []string) (*svgXform, error) {
-	switch name {
-	case "translate":
-		fa, err := parseFloats(args)
+// parseViewport reads the width, height, viewBox and preserveAspectRatio
+// attributes of an svg element. It returns the size of the viewport
+// (in the parent's user units), the transform from the element's
+// user coordinates to the viewport, and the size of the user
+// coordinate area visible in the viewport.
+// Missing width and height default to 100%, that is the parent viewport
+// size ref. The top-level svg has no parent viewport (ref is zero),
+// and then the size of the view box is used instead.
+func parseViewport(e *svgparser.Element, ref Vec2) (Vec2, *Matrix, Vec2, error) {
+	var size Vec2
+	var vb [4]float64
+	hasVB := e.Attributes["viewBox"] != ""
+	if hasVB {
+		var err error
+		vb, err = parseViewBox(e.Attributes["viewBox"])
 		if err != nil {
-			return nil, err
-		}
-		if len(fa) != 1 && len(fa) != 2 {
-			return nil, fmt.Errorf("translate should have one or two parameters: got %s", args)
+			return Vec2{}, nil, Vec2{}, err
 		}
-		if len(fa) == 1 {
-			fa = append(fa, 0)
-		}
-		return svgXformTranslate(fa[0], fa[1]), nil
-	case "matrix":
-		fa, err := parseFloats(args)
-		if err != nil {
-			return nil, err
+	}
+	for i, attr := range []string{"width", "height"} {
+		r := ref[i]
+		if r == 0 && hasVB {
+			r = vb[i+2]
 		}
-		if len(fa) != 6 {
-			return nil, fmt.Errorf("matrix transform should have 6 parameters: got %s", args)
-		}
-		return &svgXform{
-			M: [3][3]float64{
-				{fa[0], fa[2], fa[4]},
-				{fa[1], fa[3], fa[5]},
-				{0, 0, 1},
-			},
-		}, nil
-	case "scale":
-		fa, err := parseFloats(args)
+		d, err := parseLengthAttr(e.Attributes, attr, r, r)
 		if err != nil {
-			return nil, err
-		}
-		if len(fa) != 1 && len(fa) != 2 {
-			return nil, fmt.Errorf("scale should have one or two parameters: got %s", args)
+			return Vec2{}, nil, Vec2{}, err
 		}
-		if len(fa) == 1 {
-			fa = append(fa, fa[0])
+		if d <= 0 {
+			return Vec2{}, nil, Vec2{}, fmt.Errorf("svg element has no usable %s or viewBox", attr)
 		}
-		return svgXformScale(fa[0], fa[1]), nil
-	default:
-		return nil, fmt.Errorf("unknown transform function %q", name)
-	}
-}
-
-func parseSVGXForm(x string) (*svgXform, error) {
-	var s scanner.Scanner
-	xf := svgIdentity
-	s.Init(strings.NewReader(x))
-	state := xfsName
-	fname := ""
-	var args []string
-	var neg bool
-	for tok := s.Scan(); tok != scanner.EOF; tok = s.Scan() {
-		switch state {
-		case xfsName:
-			if tok != scanner.Ident {
-				return nil, fmt.Errorf("failed to parse transform: expected transform name, but got %q", s.TokenText())
-			}
-			fname = s.TokenText()
-			state = xfsBra
-		case xfsBra:
-			if tok != '(' {
-				return nil, fmt.Errorf("failed to parse transform: expected (, but got %q", s.TokenText())
-			}
-			state = xfsArg
-		case xfsMaybeComma:
-			if tok == ',' {
-				continue
-			}
-			fallthrough
-		case xfsArg:
-			if tok == ')' {
-				if neg {
-					neg = false
-					return nil, fmt.Errorf("don't understand -)")
-				}
-				newxform, err := parseSingleXform(fname, args)
-				if err != nil {
-					return nil, err
-				}
-				xf = xf.Compose(newxform)
-				state = xfsName
-				args = nil
-			} else if tok == '-' {
-				neg = !neg
-			} else if tok == scanner.Float || tok == scanner.Int {
-				if neg {
-					args = append(args, "-"+s.TokenText())
-				} else {
-					args = append(args, s.TokenText())
-				}
-				state = xfsMaybeComma
-				neg = false
-			} else {
-				return nil, fmt.Errorf("unexpected token %q parsing transform %q", s.TokenText(), x)
-			}
+		size[i] = d
+	}
+	if !hasVB {
+		return size, Identity(), size, nil
+	}
+	xf, err := viewBoxXform(vb, e.Attributes["preserveAspectRatio"], size[0], size[1])
+	if err != nil {
+		return Vec2{}, nil, Vec2{}, err
+	}
+	return size, xf, Vec2{vb[2], vb[3]}, nil
+}
+
+func parseLine(ps *Paths, xform *Matrix, vp Vec2, e *svgparser.Element) error {
+	var ferr error
+	pl := func(name string, ref float64) float64 {
+		if ferr != nil {
+			return 0
 		}
+		f, err := parseLengthAttr(e.Attributes, name, ref, 0)
+		ferr = err
+		return f
 	}
-	if state != xfsName {
-		return nil, fmt.Errorf("failed to parse transform: %q", x)
+	x1 := pl("x1", vp[0])
+	x2 := pl("x2", vp[0])
+	y1 := pl("y1", vp[1])
+	y2 := pl("y2", vp[1])
+	if ferr != nil {
+		return ferr
 	}
-	return xf, nil
+	ps.P = append(ps.P, Path{V: []Vec2{xform.Apply(Vec2{x1, y1}), xform.Apply(Vec2{x2, y2})}})
+	return nil
 }
 
 type cmdType int
@@ -241,6 +165,10 @@ const (
 	cmdHorLine
 	cmdVerLine
 	cmdCurve
+	cmdSmoothCurve
+	cmdQuad
+	cmdSmoothQuad
+	cmdArc
 )
 
 func (c cmdType) Args() int {
@@ -251,283 +179,568 @@ func (c cmdType) Args() int {
 		return 2
 	case cmdHorLine, cmdVerLine:
 		return 1
+	case cmdSmoothQuad:
+		return 2
+	case cmdSmoothCurve, cmdQuad:
+		return 4
 	case cmdCurve:
 		return 6
+	case cmdArc:
+		return 7
 	default:
 		return 0
 	}
 }
 
-type pathTokenizer struct {
-	b *bytes.Buffer
-}
-
-const (
-	eofRune   = rune(-1)
-	floatRune = rune(-2)
-)
-
-type pathToken struct {
-	r rune
-	f float64
+// pathCommands maps each (lower-case) path command letter
+// to its command type.
+var pathCommands = map[byte]cmdType{
+	'm': cmdMove,
+	'l': cmdLine,
+	'h': cmdHorLine,
+	'v': cmdVerLine,
+	'c': cmdCurve,
+	's': cmdSmoothCurve,
+	'q': cmdQuad,
+	't': cmdSmoothQuad,
+	'a': cmdArc,
 }
 
-func (pt pathToken) String() string {
-	if pt.r == eofRune {
-		return "tokEOF{}"
-	} else if pt.r == floatRune {
-		return fmt.Sprintf("tokFloat{%g}", pt.f)
+// toLower returns the lower-case version of an ASCII letter.
+func toLower(c byte) byte {
+	if c >= 'A' && c <= 'Z' {
+		return c + 'a' - 'A'
 	}
-	return fmt.Sprintf("tokRune{%c}", pt.r)
+	return c
 }
 
-func (pt *pathTokenizer) nextFloat() (pathToken, error) {
-	var b bytes.Buffer
+// parsePath adds the subpaths of a path element. Syntax errors in
+// the path data are reported as a *PathDataError.
+func parsePath(ps *Paths, xf *Matrix, e *svgparser.Element) error {
+	pl := &pathLexer{s: e.Attributes["d"], id: e.Attributes["id"]}
+	var xy [7]float64
+	var xyp int
+	var rel bool
+	var first, last Vec2
+	// ctrl is the last control point of the previous command,
+	// if it was a curve, used to construct smooth curves.
+	var ctrl Vec2
+	var firstSet bool
+	var letter byte // the letter of the current command
+	cmd, prevCmd := cmdNone, cmdNone
+	// addSegment adds a segment of shape s (in output coordinates)
+	// that goes to v.
+	addSegment := func(v Vec2, s Segment) {
+		ps.segment(xf.Apply(v), s)
+	}
+	addPoint := func(v Vec2) {
+		addSegment(v, Segment{})
+	}
+	pl.skipSpace()
 	for {
-		r, _, err := pt.b.ReadRune()
-		if err == io.EOF {
-			break
+		if xyp != 0 && (pl.eof() || pathCommands[toLower(pl.peek())] != cmdNone || toLower(pl.peek()) == 'z') {
+			return pl.errorf(pl.pos, "%c command needs %d arguments, got %d", letter, cmd.Args(), xyp)
 		}
-		if err != nil {
-			return pathToken{}, err
+		if pl.eof() {
+			return nil
+		}
+		p := pl.peek()
+		lp := toLower(p)
+		if c, ok := pathCommands[lp]; ok {
+			if c != cmdMove && !firstSet {
+				return pl.errorf(pl.pos, "path data must start with a moveto")
+			}
+			cmd, rel, letter = c, (p == lp), p
+			pl.pos++
+			pl.skipSpace()
+			continue
 		}
-		if (r >= '0' && r <= '9') || r == '.' || r == '-' {
-			b.WriteRune(r)
+		if lp == 'z' {
+			// Close Path
+			if !firstSet {
+				return pl.errorf(pl.pos, "path data must start with a moveto")
+			}
+			if last != first {
+				addPoint(first)
+			}
+			ps.P[len(ps.P)-1].Closed = true
+			last = first
+			prevCmd = cmdNone
+			// Numbers can't follow a close path without a command.
+			cmd = cmdNone
+			pl.pos++
+			pl.skipSpace()
 			continue
 		}
-		if err := pt.b.UnreadRune(); err != nil {
-			return pathToken{}, err
+		if p != '+' && p != '-' && p != '.' && !isDigit(p) {
+			return pl.errorf(pl.pos, "unknown command %q", p)
 		}
-		break
-	}
-	f, err := strconv.ParseFloat(b.String(), 64)
-	return pathToken{r: floatRune, f: f}, err
-}
-
-func (pt *pathTokenizer) Next() (pathToken, error) {
-	for {
-		r, _, err := pt.b.ReadRune()
-		if err == io.EOF {
-			return pathToken{r: eofRune}, nil
+		if cmd == cmdNone {
+			if !firstSet {
+				return pl.errorf(pl.pos, "path data must start with a moveto")
+			}
+			return pl.errorf(pl.pos, "number without a command")
 		}
+		f, err := pl.number(cmd == cmdArc && (xyp == 3 || xyp == 4))
 		if err != nil {
-			return pathToken{}, nil
+			return err
 		}
-		if r == ',' || r == ' ' || unicode.IsSpace(r) {
+		xy[xyp] = f
+		xyp++
+		if xyp < cmd.Args() {
 			continue
 		}
-		if r >= '0' && r <= '9' || r == '-' {
-			if err := pt.b.UnreadRune(); err != nil {
-				return pathToken{}, err
+		if cmd == cmdMove {
+			ps.P = append(ps.P, Path{})
+		} else if ps.P[len(ps.P)-1].Closed {
+			// Drawing after a closepath starts a new subpath at
+			// the start of the closed one.
+			ps.P = append(ps.P, Path{V: []Vec2{xf.Apply(last)}})
+		}
+		// pt returns the i'th point argument, which is relative
+		// to the current point if the command is relative.
+		pt := func(i int) Vec2 {
+			v := Vec2{xy[i], xy[i+1]}
+			if rel {
+				v = v.Add(last)
 			}
-			return pt.nextFloat()
+			return v
 		}
-		return pathToken{r: r}, nil
+		var v Vec2
+		switch cmd {
+		case cmdHorLine:
+			v = Vec2{xy[0], last[1]}
+			if rel {
+				v[0] += last[0]
+			}
+			addPoint(v)
+		case cmdVerLine:
+			v = Vec2{last[0], xy[0]}
+			if rel {
+				v[1] += last[1]
+			}
+			addPoint(v)
+		case cmdCurve, cmdSmoothCurve:
+			var p1, p2 Vec2
+			if cmd == cmdCurve {
+				p1, p2, v = pt(0), pt(2), pt(4)
+			} else {
+				p1 = last
+				if prevCmd == cmdCurve || prevCmd == cmdSmoothCurve {
+					p1 = vec2reflect(ctrl, last)
+				}
+				p2, v = pt(0), pt(2)
+			}
+			addSegment(v, Segment{Kind: CubicSegment, C: [2]Vec2{xf.Apply(p1), xf.Apply(p2)}})
+			ctrl = p2
+		case cmdQuad, cmdSmoothQuad:
+			var p1 Vec2
+			if cmd == cmdQuad {
+				p1, v = pt(0), pt(2)
+			} else {
+				p1 = last
+				if prevCmd == cmdQuad || prevCmd == cmdSmoothQuad {
+					p1 = vec2reflect(ctrl, last)
+				}
+				v = pt(0)
+			}
+			addSegment(v, Segment{Kind: QuadSegment, C: [2]Vec2{xf.Apply(p1)}})
+			ctrl = p1
+		case cmdArc:
+			v = pt(5)
+			// An arc whose ends are the same isn't drawn.
+			if v != last {
+				addSegment(v, arcSegment(xf, last, xy[0], xy[1], xy[2]*math.Pi/180, xy[3] != 0, xy[4] != 0, v))
+			}
+		case cmdLine, cmdMove:
+			v = pt(0)
+			addPoint(v)
+		}
+		if cmd == cmdMove || !firstSet {
+			first = v
+			firstSet = true
+		}
+		last = v
+		prevCmd = cmd
+		if cmd == cmdMove {
+			cmd = cmdLine
+		}
+		xyp = 0
 	}
 }
 
-func vec2AddVec2(a, b Vec2) Vec2 {
-	return Vec2{a[0] + b[0], a[1] + b[1]}
+// svgParser holds the state used while extracting paths from an
+// svg document.
+type svgParser struct {
+	root   *svgparser.Element
+	bounds Bounds  // the bounds of the output paths
+	xf     *Matrix // maps the root's user coordinates to the output
+	vp     Vec2    // size of the root viewport in user coordinates
+
+	pm    map[string]*Paths             // where to put paths from elements with these ids
+	byID  map[string]*svgparser.Element // all elements that have an id
+	using map[*svgparser.Element]bool   // elements being instanced by <use>
+	css   []cssRule                     // rules from all stylesheets
+
+	source map[*svgparser.Element]*elementSource
+
+	badColors map[string]bool // unknown colours that have been warned about
+
+	opts     ParseOptions
+	tol      float64 // the tolerance used to flatten the outlines of clip paths and masks
+	warnings []Warning
+
+	// If byColor is set, paths are added to layers by stroke
+	// colour rather than to the output of the svgState.
+	// If byLayer is set, each Inkscape layer gets its own output.
+	byColor  bool
+	byLayer  bool
+	layers   []*Layer
+	layerMap map[string]*Layer
+}
+
+// A Layer is a named set of paths, for example all the paths
+// that are to be drawn with one pen.
+type Layer struct {
+	Name  string
+	Paths *Paths
+}
+
+// svgState describes the context in which an element is drawn.
+// It's inherited from the element's parent.
+type svgState struct {
+	out      *Paths   // where paths are added, or nil if the element isn't rendered
+	style    svgStyle // the computed style of the element
+	xf       *Matrix  // maps user coordinates to output coordinates
+	vp       Vec2     // size in user coordinates of the nearest enclosing viewport
+	instance bool     // whether we're drawing the contents of a <use>
+	clip     region   // if set, paths are clipped to this region
+	attrs    Attrs    // the layer and id given to the paths that are drawn
+
+	// If shapes is set, the outlines of the shapes that are found
+	// are collected here, whether or not they're stroked.
+	shapes *[]svgShape
+}
+
+// svgIgnored are elements that don't contain any paths, and that are
+// skipped silently.
+var svgIgnored = map[string]bool{
+	"desc":           true,
+	"filter":         true,
+	"linearGradient": true,
+	"metadata":       true,
+	"namedview":      true,
+	"radialGradient": true,
+	"script":         true,
+	"style":          true,
+	"title":          true,
+}
+
+// svgNamespace is the namespace of svg elements.
+const svgNamespace = "http://www.w3.org/2000/svg"
+
+// foreign reports whether e is in a namespace other than svg's, like
+// the metadata that Inkscape and Sodipodi add. Such elements are
+// skipped silently.
+func (sp *svgParser) foreign(e *svgparser.Element) bool {
+	src := sp.source[e]
+	return src != nil && src.space != "" && src.space != svgNamespace
 }
 
-func bez1(p0, p1, p2, p3 Vec2, t float64) Vec2 {
-	a, b, c, d := (1-t)*(1-t)*(1-t), 3*(1-t)*(1-t)*t, 3*(1-t)*t*t, t*t*t
-	return Vec2{a*p0[0] + b*p1[0] + c*p2[0] + d*p3[0], a*p0[1] + b*p1[1] + c*p2[1] + d*p3[1]}
+// svgNotRendered are container elements whose contents aren't drawn
+// directly, but which may be referenced elsewhere.
+var svgNotRendered = map[string]bool{
+	"clipPath": true,
+	"defs":     true,
+	"marker":   true,
+	"mask":     true,
+	"pattern":  true,
+	"symbol":   true,
 }
 
-func bezierInterpolate(target []Vec2, p0, p1, p2, p3 Vec2, start, end, d float64) []Vec2 {
-	vs := bez1(p0, p1, p2, p3, start)
-	ve := bez1(p0, p1, p2, p3, end)
-	if end-start < 0.25 && vec2dist(vs, ve) < d {
-		target = append(target, ve)
-		return target
+// index records all elements in the tree rooted at e by id, and
+// reads the rules from any stylesheets.
+func (sp *svgParser) index(e *svgparser.Element) {
+	if id := e.Attributes["id"]; id != "" {
+		if _, ok := sp.byID[id]; !ok {
+			sp.byID[id] = e
+		}
+	}
+	if e.Name == "style" {
+		sp.css = parseStylesheet(sp.css, e.Content)
+	}
+	for _, c := range e.Children {
+		sp.index(c)
 	}
-	target = bezierInterpolate(target, p0, p1, p2, p3, start, (start+end)/2, d)
-	return bezierInterpolate(target, p0, p1, p2, p3, (start+end)/2, end, d)
 }
 
-func parsePath(ps *Paths, xf *svgXform, e *svgparser.Element) error {
-	bb := &pathTokenizer{bytes.NewBufferString(e.Attributes["d"])}
-	var xy [6]float64
-	var xyp int
-	var rel bool
-	var first, last Vec2
-	var firstSet bool
-	cmd := cmdNone
-	for {
-		token, err := bb.Next()
-		if err != nil {
+// target returns the paths that lines drawn in colour c in state
+// st should be added to, or nil if they're not drawn.
+func (sp *svgParser) target(st svgState, c rgb) *Paths {
+	if st.out == nil || !sp.byColor {
+		return st.out
+	}
+	name := c.String()
+	l, ok := sp.layerMap[name]
+	if !ok {
+		l = &Layer{Name: name, Paths: &Paths{Bounds: sp.bounds}}
+		sp.layers = append(sp.layers, l)
+		sp.layerMap[name] = l
+	}
+	return l.Paths
+}
+
+// parseChildren adds the paths found in the children of e.
+func (sp *svgParser) parseChildren(st svgState, e *svgparser.Element) error {
+	for _, c := range e.Children {
+		if err := sp.parseElement(st, c); err != nil {
 			return err
 		}
-		if token.r == eofRune {
-			if xyp != 0 {
-				return fmt.Errorf("got stray component in path")
-			}
+	}
+	return nil
+}
+
+// parseElement adds the paths found in e to st.out, or to
+// the entry of sp.pm if the id of e matches.
+func (sp *svgParser) parseElement(st svgState, c *svgparser.Element) error {
+	if svgIgnored[c.Name] || sp.foreign(c) {
+		return nil
+	}
+	st.style = sp.computeStyle(st.style, c)
+	st.attrs.ID = c.Attributes["id"]
+	if st.style["display"] == "none" && !svgNotRendered[c.Name] {
+		// Hidden elements and their children aren't drawn.
+		return nil
+	}
+	if svgNotRendered[c.Name] {
+		if st.shapes != nil {
 			return nil
 		}
-		p := token.r
-		lp := unicode.ToLower(p)
-		if lp == 'm' {
-			// Move
-			if xyp != 0 {
-				return fmt.Errorf("got stray components before %c", p)
-			}
-			cmd, rel = cmdMove, (p == lp)
-		} else if lp == 'l' {
-			// Line To
-			if xyp != 0 {
-				return fmt.Errorf("got stray components before %c", p)
-			}
-			cmd, rel = cmdLine, (p == lp)
-		} else if lp == 'v' || lp == 'h' {
-			if xyp != 0 {
-				return fmt.Errorf("got stray components before %c", p)
-			}
-			cmd, rel = cmdHorLine, (p == lp)
-			if lp == 'v' {
-				cmd = cmdVerLine
+		st.out = nil
+	}
+	if id := c.Attributes["id"]; id != "" && !st.instance && st.shapes == nil {
+		if namedP, ok := sp.pm[id]; ok {
+			st.out = namedP
+		}
+	}
+	exf, err := parseSVGXForm(c.Attributes["transform"])
+	if err != nil {
+		return fmt.Errorf("%s element: %v", c.Name, err)
+	}
+	st.xf = st.xf.Compose(exf)
+	if st.out != nil || st.shapes != nil {
+		r, err := sp.clipRegion(st, c)
+		if err != nil {
+			return err
+		}
+		st.clip = intersect(st.clip, r)
+	}
+	return sp.parseContent(st, c)
+}
+
+// parseContent adds the paths found in e, whose style and
+// transform have already been applied to st.
+func (sp *svgParser) parseContent(st svgState, c *svgparser.Element) error {
+	switch c.Name {
+	case "g":
+		if c.Attributes["groupmode"] == "layer" {
+			name := c.Attributes["label"]
+			if name == "" {
+				name = c.Attributes["id"]
 			}
-		} else if lp == 'z' {
-			// Close Path
-			if !firstSet {
-				return fmt.Errorf("got close path %c before any points", p)
+			st.attrs.Layer = name
+			if sp.byLayer && st.out != nil && st.shapes == nil {
+				l := &Layer{Name: name, Paths: &Paths{Bounds: sp.bounds}}
+				sp.layers = append(sp.layers, l)
+				st.out = l.Paths
 			}
-			if xyp != 0 {
-				return fmt.Errorf("got stray components before %c", p)
+		}
+		return sp.parseChildren(st, c)
+	case "defs", "symbol", "clipPath", "mask", "marker", "pattern":
+		return sp.parseChildren(st, c)
+	case "svg":
+		// A nested svg element establishes a new viewport.
+		var pos Vec2
+		for i, attr := range []string{"x", "y"} {
+			d, err := parseLengthAttr(c.Attributes, attr, st.vp[i], 0)
+			if err != nil {
+				return err
 			}
-			ps.P[len(ps.P)-1].V = append(ps.P[len(ps.P)-1].V, xf.Apply(first))
-			last = first
-		} else if lp == 'c' {
-			// Curve To
-			cmd, rel = cmdCurve, (p == lp)
-		} else if p == floatRune {
-			xy[xyp] = token.f
-			xyp++
-			if xyp > cmd.Args() {
-				return fmt.Errorf("got unexpected float value %v", xy[xyp-1])
+			pos[i] = d
+		}
+		_, vbxf, nvp, err := parseViewport(c, st.vp)
+		if err != nil {
+			return err
+		}
+		st.xf = st.xf.Compose(Translate(pos)).Compose(vbxf)
+		st.vp = nvp
+		return sp.parseChildren(st, c)
+	}
+	if st.out == nil && st.shapes == nil {
+		// The element isn't rendered here, for example because it's
+		// in defs, so it doesn't matter if it's unsupported.
+		return nil
+	}
+	if c.Name == "use" {
+		return sp.parseUse(st, c)
+	}
+	if v := st.style["visibility"]; v == "hidden" || v == "collapse" {
+		return nil
+	}
+	if v, ok := st.style.unknownColor(); ok && !sp.badColors[v] {
+		sp.badColors[v] = true
+		if err := sp.warn(c, "unknown colour %q is drawn in black", v); err != nil {
+			return err
+		}
+	}
+	if c.Name == "text" && sp.opts.Font != nil {
+		if st.shapes != nil {
+			// Single-stroke text has no area.
+			return nil
+		}
+		// Text is usually filled rather than stroked, so
+		// unstroked text is drawn in its fill colour.
+		if _, ok := st.style.paintColor("stroke", "none"); !ok {
+			if _, ok := st.style.paintColor("fill", "black"); !ok {
+				return nil
 			}
-			if xyp == cmd.Args() {
-				if cmd == cmdMove {
-					path := Path{}
-					ps.P = append(ps.P, path)
-				}
-				var v Vec2
-				switch cmd {
-				case cmdHorLine:
-					v = Vec2{xy[0], last[1]}
-					if rel {
-						v[0] += last[0]
-					}
-					ps.P[len(ps.P)-1].V = append(ps.P[len(ps.P)-1].V, xf.Apply(v))
-				case cmdVerLine:
-					v = Vec2{last[0], xy[1]}
-					if rel {
-						v[1] += last[1]
-					}
-					ps.P[len(ps.P)-1].V = append(ps.P[len(ps.P)-1].V, xf.Apply(v))
-				case cmdCurve:
-					p0 := last
-					p1 := Vec2{xy[0], xy[1]}
-					p2 := Vec2{xy[2], xy[3]}
-					p3 := Vec2{xy[4], xy[5]}
-					if rel {
-						p1 = vec2AddVec2(p1, last)
-						p2 = vec2AddVec2(p2, last)
-						p3 = vec2AddVec2(p3, last)
-					}
-					v = p3
-					for _, v := range bezierInterpolate([]Vec2{}, p0, p1, p2, p3, 0, 1, 0.5) {
-						ps.P[len(ps.P)-1].V = append(ps.P[len(ps.P)-1].V, xf.Apply(v))
-					}
-				case cmdLine, cmdMove:
-					v = Vec2{xy[xyp-2], xy[xyp-1]}
-					if rel {
-						v = vec2AddVec2(v, last)
-					}
-					ps.P[len(ps.P)-1].V = append(ps.P[len(ps.P)-1].V, xf.Apply(v))
-				}
-				if !firstSet {
-					first = v
-					firstSet = true
-				}
-				last = v
-				if cmd == cmdMove {
-					cmd = cmdLine
-				}
-				xyp = 0
+			style := svgStyle{}
+			for k, v := range st.style {
+				style[k] = v
 			}
-		} else {
-			return fmt.Errorf("got unknown token %c", p)
+			style["stroke"] = style["fill"]
+			st.style = style
 		}
+		return sp.draw(st, false, func(out *Paths) error {
+			sp.parseText(out, st, c)
+			return nil
+		})
 	}
+	_, stroked := st.style.paintColor("stroke", "black")
+	_, filled := st.style.paintColor("fill", "black")
+	filled = filled && sp.opts.Fill != nil && sp.opts.Fill.PenWidth > 0
+	if !stroked && !filled && st.shapes == nil {
+		// Elements with no stroke aren't drawn, unless their
+		// fill is hatched.
+		return nil
+	}
+	switch c.Name {
+	case "path":
+		return sp.draw(st, filled, func(out *Paths) error { return parsePath(out, st.xf, c) })
+	case "line":
+		return sp.draw(st, filled, func(out *Paths) error { return parseLine(out, st.xf, st.vp, c) })
+	case "rect":
+		return sp.draw(st, filled, func(out *Paths) error { return parseRect(out, st.xf, st.vp, c) })
+	case "circle", "ellipse":
+		return sp.draw(st, filled, func(out *Paths) error { return parseEllipse(out, st.xf, st.vp, c) })
+	case "polyline", "polygon":
+		return sp.draw(st, filled, func(out *Paths) error { return parsePolyline(out, st.xf, c) })
+	case "text":
+		return sp.warn(c, "text isn't drawn without a font")
+	}
+	return sp.warn(c, "unsupported element")
 }
 
-type svgXform struct {
-	M [3][3]float64
-}
-
-func (xf *svgXform) Compose(xf2 *svgXform) *svgXform {
-	var a svgXform
-	for i := 0; i < 3; i++ {
-		for j := 0; j < 3; j++ {
-			for k := 0; k < 3; k++ {
-				a.M[i][k] += xf.M[i][j] * xf2.M[j][k]
-			}
+// draw calls f to draw an element in state st. If the element is
+// stroked, the paths are added to its target, and if fill is set,
+// so are lines that hatch its fill. Or the paths are recorded as a
+// shape if st is collecting shapes.
+func (sp *svgParser) draw(st svgState, fill bool, f func(out *Paths) error) error {
+	if st.shapes != nil {
+		var ps Paths
+		if err := f(&ps); err != nil {
+			return err
 		}
+		// Shapes are only used as regions, which are polygons.
+		ps.Flatten(sp.tol)
+		*st.shapes = append(*st.shapes, svgShape{P: ps.P, style: st.style, clip: st.clip})
+		return nil
 	}
-	return &a
+	if st.out == nil {
+		return nil
+	}
+	var ps Paths
+	if err := f(&ps); err != nil {
+		return err
+	}
+	if c, ok := st.style.paintColor("fill", "black"); ok && fill {
+		sp.add(st, c, sp.hatchFill(st, c, ps.P))
+	}
+	if c, ok := st.style.paintColor("stroke", "black"); ok {
+		sp.add(st, c, ps.P)
+	}
+	return nil
 }
 
-func (xf *svgXform) Apply(v Vec2) Vec2 {
-	x := [3]float64{v[0], v[1], 1.0}
-	var r [3]float64
-	for i := 0; i < 3; i++ {
-		for j := 0; j < 3; j++ {
-			r[i] += xf.M[i][j] * x[j]
-		}
+// add adds paths drawn in colour c to the target of state st.
+// Their attributes are set from the state and the colour, and
+// they're clipped if necessary.
+func (sp *svgParser) add(st svgState, c rgb, ps []Path) {
+	attrs := st.attrs
+	attrs.Color = c.String()
+	for i := range ps {
+		ps[i].Attrs = attrs
+	}
+	if st.clip != nil {
+		ps = clipToRegion(ps, st.clip)
 	}
-	return Vec2{r[0] / r[2], r[1] / r[2]}
+	out := sp.target(st, c)
+	out.P = append(out.P, ps...)
 }
 
-var svgIdentity = &svgXform{
-	M: [3][3]float64{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}},
-}
+// parseUse draws an instance of the element referenced by a <use>.
+func (sp *svgParser) parseUse(st svgState, c *svgparser.Element) error {
+	href := c.Attributes["href"]
+	if !strings.HasPrefix(href, "#") {
+		return sp.warn(c, "unsupported href %q", href)
+	}
+	ref, ok := sp.byID[href[1:]]
+	if !ok {
+		return sp.warn(c, "references missing element %q", href)
+	}
+	if sp.using[ref] {
+		return fmt.Errorf("use element references %q, which contains itself", href)
+	}
+	sp.using[ref] = true
+	defer delete(sp.using, ref)
 
-func parsePaths(p *Paths, pm map[string]*Paths, xform *svgXform, e *svgparser.Element) error {
-	for _, c := range e.Children {
-		cp := p
-		id := c.Attributes["id"]
-		if namedP, ok := pm[id]; id != "" && ok {
-			cp = namedP
-		}
-		switch c.Name {
-		case "g":
-			gxf, err := parseSVGXForm(c.Attributes["transform"])
-			if err != nil {
-				return err
-			}
-			xf2 := xform.Compose(gxf)
-			if err := parsePaths(cp, pm, xf2, c); err != nil {
-				return err
-			}
-		case "path":
-			if err := parsePath(cp, xform, c); err != nil {
-				return err
-			}
-		case "line":
-			if err := parseLine(cp, xform, c); err != nil {
-				return err
-			}
-		case "defs":
-			continue
-		default:
-			fmt.Fprintf(os.Stderr, "unknown child node type %q\n", c.Name)
+	// The use's own transform has already been applied.
+	a, err := lengthAttrs(c, []string{"x", "y"}, []float64{st.vp[0], st.vp[1]})
+	if err != nil {
+		return err
+	}
+	st.xf = st.xf.Compose(Translate(Vec2{a[0], a[1]}))
+	st.instance = true
+
+	if ref.Name != "symbol" && ref.Name != "svg" {
+		return sp.parseElement(st, ref)
+	}
+	// Symbols and svg elements establish a viewport, whose size is
+	// given by the use element if it has a width or height.
+	vpe := &svgparser.Element{Name: ref.Name, Attributes: map[string]string{}}
+	for k, v := range ref.Attributes {
+		vpe.Attributes[k] = v
+	}
+	for _, attr := range []string{"width", "height"} {
+		if v := c.Attributes[attr]; v != "" {
+			vpe.Attributes[attr] = v
 		}
 	}
-	return nil
+	_, vbxf, nvp, err := parseViewport(vpe, st.vp)
+	if err != nil {
+		return err
+	}
+	st.xf = st.xf.Compose(vbxf)
+	st.vp = nvp
+	return sp.parseChildren(st, ref)
 }
 
-func IDsFromSVG(r io.Reader, ids []string) (map[string]*Paths, error) {
+// newSVGParser reads an svg document, and prepares to extract
+// paths from it.
+func newSVGParser(r io.Reader, opts *ParseOptions) (*svgParser, error) {
+	if opts == nil {
+		opts = &ParseOptions{}
+	}
 	raw, err := ioutil.ReadAll(r)
 	if err != nil {
 		return nil, err
@@ -541,30 +754,176 @@ func IDsFromSVG(r io.Reader, ids []string) (map[string]*Paths, error) {
 	if err := elt.Decode(decoder); err != nil && err != io.EOF {
 		return nil, err
 	}
-	bs, err := parseBounds(elt)
+	unit, err := outputUnits(opts.Unit, elt)
 	if err != nil {
 		return nil, err
 	}
-	pathMap := map[string]*Paths{
-		"": {Bounds: bs},
+	size, vbxf, vp, err := parseViewport(elt, Vec2{})
+	if err != nil {
+		return nil, err
+	}
+	sp := &svgParser{
+		root:      elt,
+		xf:        Scale(unit, unit).Compose(vbxf),
+		bounds:    Bounds{Max: size.Scale(unit)},
+		opts:      *opts,
+		vp:        vp,
+		pm:        map[string]*Paths{},
+		byID:      map[string]*svgparser.Element{},
+		using:     map[*svgparser.Element]bool{},
+		layerMap:  map[string]*Layer{},
+		badColors: map[string]bool{},
+	}
+	sp.tol = opts.Tolerance
+	if sp.tol <= 0 {
+		sp.tol = curveTolerance / mmPerPx * unit
+	}
+	sp.index(elt)
+	sp.source, err = indexSource(raw, elt)
+	if err != nil {
+		return nil, err
+	}
+	return sp, nil
+}
+
+// elementSource is what's known about an element from the source of
+// the document, which svgparser doesn't keep.
+type elementSource struct {
+	// space is the namespace of the element's name.
+	space string
+	// text[i] is the character data before the element's i'th
+	// child, and the last is the character data after them all.
+	// svgparser keeps only the last piece.
+	text []string
+}
+
+// indexSource reads the document raw again, alongside the tree rooted
+// at root that svgparser made from it, and returns the source of each
+// element of the tree.
+func indexSource(raw []byte, root *svgparser.Element) (map[*svgparser.Element]*elementSource, error) {
+	// svgparser adds the elements to the tree in document order.
+	var order []*svgparser.Element
+	var walk func(e *svgparser.Element)
+	walk = func(e *svgparser.Element) {
+		order = append(order, e)
+		for _, c := range e.Children {
+			walk(c)
+		}
+	}
+	walk(root)
+
+	src := map[*svgparser.Element]*elementSource{}
+	var open []*elementSource
+	decoder := xml.NewDecoder(bytes.NewReader(raw))
+	decoder.CharsetReader = charset.NewReaderLabel
+	for len(src) < len(order) || len(open) > 0 {
+		t, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := t.(type) {
+		case xml.StartElement:
+			if len(src) == len(order) {
+				return nil, fmt.Errorf("%s element isn't in the document tree", t.Name.Local)
+			}
+			es := &elementSource{space: t.Name.Space, text: []string{""}}
+			src[order[len(src)]] = es
+			open = append(open, es)
+		case xml.CharData:
+			if len(open) > 0 {
+				es := open[len(open)-1]
+				es.text[len(es.text)-1] += string(t)
+			}
+		case xml.EndElement:
+			if len(open) == 0 {
+				return nil, fmt.Errorf("unexpected end of %s element", t.Name.Local)
+			}
+			open = open[:len(open)-1]
+			if len(open) > 0 {
+				es := open[len(open)-1]
+				es.text = append(es.text, "")
+			}
+		}
+	}
+	return src, nil
+}
+
+// parse adds all the paths in the document to out.
+func (sp *svgParser) parse(out *Paths) error {
+	st := svgState{
+		out:   out,
+		style: sp.computeStyle(nil, sp.root),
+		xf:    sp.xf,
+		vp:    sp.vp,
 	}
+	return sp.parseChildren(st, sp.root)
+}
 
+// IDsFromSVG parses an SVG file, like FromSVG. Paths from elements
+// (and their children) whose id is in ids are returned separately in the
+// map, keyed by id. All other paths are returned under the key "".
+// Elements inside <defs> and <symbol> aren't drawn, but are returned
+// here if their id is requested.
+func IDsFromSVG(r io.Reader, ids []string) (map[string]*Paths, error) {
+	sp, err := newSVGParser(r, nil)
+	if err != nil {
+		return nil, err
+	}
+	sp.pm[""] = &Paths{Bounds: sp.bounds}
 	for _, id := range ids {
-		if _, ok := pathMap[id]; ok {
+		if _, ok := sp.pm[id]; ok {
 			return nil, fmt.Errorf("id %q appears twice or more", id)
 		}
-		pathMap[id] = &Paths{Bounds: bs}
+		sp.pm[id] = &Paths{Bounds: sp.bounds}
 	}
-	return pathMap, parsePaths(pathMap[""], pathMap, svgIdentity, elt)
+	return sp.pm, sp.parse(sp.pm[""])
+}
+
+// ColorLayersFromSVG parses an SVG file, like FromSVG, and groups
+// the paths into layers by their stroke colour. Each layer is
+// named by its colour in #rrggbb form, and the layers are returned
+// in the order their colours first appear in the file.
+// The stroke colour can be set by presentation attributes, inline
+// styles, or stylesheets (with simple selectors), and is inherited
+// from parent elements. Elements with no stroke colour set are
+// placed in the #000000 (black) layer.
+func ColorLayersFromSVG(r io.Reader) ([]*Layer, error) {
+	layers, _, err := ColorLayersFromSVGWithOptions(r, nil)
+	return layers, err
+}
+
+// InkscapeLayersFromSVG parses an SVG file, like FromSVG, and
+// returns the paths in each Inkscape layer (a group with
+// inkscape:groupmode="layer") separately, in the order the layers
+// appear in the file. Layers are named by their label, or their
+// id if they don't have one. Paths in sublayers belong only to the
+// innermost layer. Any paths that aren't in a layer are returned
+// first, in a layer with an empty name.
+// As with FromSVG, hidden layers are skipped.
+func InkscapeLayersFromSVG(r io.Reader) ([]*Layer, error) {
+	layers, _, err := InkscapeLayersFromSVGWithOptions(r, nil)
+	return layers, err
 }
 
 // FromSVG parses an SVG file, extracting paths.
-// This provides only limited SVG parsing support, and
-// will fail or produce incorrect results if the SVG file
-// uses features that it doesn't understand.
+// The paths are in the coordinates of the top-level viewport, with
+// any viewBox and preserveAspectRatio applied, in the units that its
+// width and height are given in (or CSS pixels, 96 to the inch, if
+// they have none). The bounds are set to the viewport, so that
+// Bounds.Max is the width and height of the document. Use
+// FromSVGWithOptions with a Unit of "mm" to get the paths and size
+// in millimeters, whatever units the document uses.
+// Hidden elements (with display none, visibility hidden or stroke
+// none) aren't drawn.
+// Paths are clipped by clip paths, and by masks, which are treated
+// as binary: each shape in a mask either shows or hides what's
+// beneath it, depending on whether its fill is light or dark.
+// This provides only limited SVG parsing support, and parts of
+// the file that aren't supported are skipped. Use FromSVGWithOptions
+// to find out what's been skipped.
 func FromSVG(r io.Reader) (*Paths, error) {
-	pm, err := IDsFromSVG(r, nil)
-	return pm[""], err
+	ps, _, err := FromSVGWithOptions(r, nil)
+	return ps, err
 }
 
 // SVGConfig provides configuration for the svg generated.
@@ -578,7 +937,7 @@ type SVGConfig struct {
 }
 
 var (
-	svgh = `<svg height="%s" width="%s" viewBox="%d %d %d %d" version="1.1" xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink">`
+	svgh = `<svg height="%s" width="%s" viewBox="%d %d %d %d" version="1.1" xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink" xmlns:inkscape="http://www.inkscape.org/namespaces/inkscape">`
 )
 
 func (ps *Paths) SVGWithConfig(w io.Writer, cfg *SVGConfig) error {
@@ -593,20 +952,72 @@ func (ps *Paths) SVGWithConfig(w io.Writer, cfg *SVGConfig) error {
 	wr(svgh, cfg.Height, cfg.Width, cfg.ViewBox[0], cfg.ViewBox[1], cfg.ViewBox[2], cfg.ViewBox[3])
 	wr("\n")
 	wr("<g fill=\"none\" stroke=\"black\" stroke-width=\"%s\">\n", cfg.StrokeWidth)
+	// Runs of paths on the same layer are grouped into an Inkscape
+	// layer.
+	layer := ""
 	for _, p := range ps.P {
 		if len(p.V) == 0 {
 			continue
 		}
-		wr(`<path d="`)
-		for i, v := range p.V {
-			if i == 0 {
-				wr("M %.4f %.4f", v[0], v[1])
-			} else {
+		if p.Attrs.Layer != layer {
+			if layer != "" {
+				wr("</g>\n")
+			}
+			if p.Attrs.Layer != "" {
+				wr("<g inkscape:groupmode=\"layer\" inkscape:label=\"%s\">\n", xmlEscape(p.Attrs.Layer))
+			}
+			layer = p.Attrs.Layer
+		}
+		wr("<path")
+		if p.Attrs.Color != "" {
+			wr(" stroke=\"%s\"", xmlEscape(p.Attrs.Color))
+		}
+		wr(` d="`)
+		wr("M %.4f %.4f", p.V[0][0], p.V[0][1])
+		// Coordinates after a moveto or lineto are more lines.
+		implicit := true
+		for i, v := range p.V[1:] {
+			s := p.segment(i)
+			if p.Closed && i == len(p.V)-2 && s.Kind == LineSegment {
+				// The closepath draws the last line.
+				break
+			}
+			switch s.Kind {
+			case LineSegment:
+				if !implicit {
+					wr(" L")
+				}
 				wr(" %.4f %.4f", v[0], v[1])
+			case QuadSegment:
+				wr(" Q %.4f %.4f %.4f %.4f", s.C[0][0], s.C[0][1], v[0], v[1])
+			case CubicSegment:
+				wr(" C %.4f %.4f %.4f %.4f %.4f %.4f", s.C[0][0], s.C[0][1], s.C[1][0], s.C[1][1], v[0], v[1])
+			case ArcSegment:
+				rx, ry, phi := s.Arc.axes()
+				// Arcs are drawn in pieces of at most a quarter turn,
+				// since the arc through two nearly opposite points
+				// depends too much on their exact positions.
+				n := int(math.Max(1, math.Ceil(math.Abs(s.Arc.Sweep)/(math.Pi/2)-1e-9)))
+				for k := 1; k <= n; k++ {
+					end := v
+					if k < n {
+						end = s.Arc.point(s.Arc.Start + s.Arc.Sweep*float64(k)/float64(n))
+					}
+					// Positive angles go from the x axis towards the
+					// y axis, which is clockwise on the screen.
+					wr(" A %.4f %.4f %.4f 0 %d %.4f %.4f", rx, ry, phi*180/math.Pi, svgFlag(s.Arc.ccw()), end[0], end[1])
+				}
 			}
+			implicit = s.Kind == LineSegment
+		}
+		if p.Closed {
+			wr(" Z")
 		}
 		wr("\"/>\n")
 	}
+	if layer != "" {
+		wr("</g>\n")
+	}
 	wr("</g>")
 	wr("</svg>")
 	if werr == nil {
@@ -615,10 +1026,26 @@ func (ps *Paths) SVGWithConfig(w io.Writer, cfg *SVGConfig) error {
 	return werr
 }
 
+// xmlEscape returns s with the characters that are special in XML
+// escaped.
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// svgFlag returns the value of an SVG arc flag.
+func svgFlag(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
 func (ps *Paths) DefaultConfig() *SVGConfig {
 	return &SVGConfig{
-		Width:  fmt.Sprintf("%dmm", int(ps.Bounds.Max[1])),
-		Height: fmt.Sprintf("%dmm", int(ps.Bounds.Max[0])),
+		Width:  fmt.Sprintf("%dmm", int(ps.Bounds.Max[0])),
+		Height: fmt.Sprintf("%dmm", int(ps.Bounds.Max[1])),
 		ViewBox: [4]int{
 			int(ps.Bounds.Min[0]),
 			int(ps.Bounds.Min[1]),
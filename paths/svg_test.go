@@ -2,6 +2,8 @@ package paths
 
 import (
 	"bytes"
+	"fmt"
+	"math"
 	"reflect"
 	"strings"
 	"testing"
@@ -25,15 +27,47 @@ func TestSVG(t *testing.T) {
 	if err != nil {
 		t.Fatalf("failed to parse svg: %v", err)
 	}
+	// Paths are black unless they say otherwise.
+	black := Attrs{Color: "#000000"}
 	want := &Paths{
 		Bounds: Bounds{Max: Vec2{2000, 1000}},
-		P:      []Path{{V: []Vec2{{123, 456}, {321, 654}}}, {V: []Vec2{{400, 200}, {800, 500}}}, {V: []Vec2{{400, 300}, {800, 300}, {600, 400}}}},
+		P: []Path{
+			{V: []Vec2{{123, 456}, {321, 654}}, Attrs: black},
+			{V: []Vec2{{400, 200}, {800, 500}}, Attrs: black},
+			{V: []Vec2{{400, 300}, {800, 300}, {600, 400}}, Attrs: black},
+		},
 	}
 	if !reflect.DeepEqual(got, want) {
 		t.Errorf("svg parse. Got:\n%v\nWant:\n%v\n", got, want)
 	}
 }
 
+// pathsNear reports whether two sets of paths are the same, allowing
+// for small floating-point differences.
+func pathsNear(a, b *Paths) bool {
+	const eps = 1e-9
+	near := func(x, y Vec2) bool {
+		return math.Abs(x[0]-y[0]) < eps && math.Abs(x[1]-y[1]) < eps
+	}
+	if !near(a.Bounds.Min, b.Bounds.Min) || !near(a.Bounds.Max, b.Bounds.Max) {
+		return false
+	}
+	if len(a.P) != len(b.P) {
+		return false
+	}
+	for i := range a.P {
+		if len(a.P[i].V) != len(b.P[i].V) || a.P[i].Closed != b.P[i].Closed {
+			return false
+		}
+		for j := range a.P[i].V {
+			if !near(a.P[i].V[j], b.P[i].V[j]) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
 // TestSVGRoundTrip parses paths out of an svg, writes them back
 // to a new svg file, parses the paths out of that, and then checks
 // that the paths (or bounds) don't change.
@@ -58,3 +92,936 @@ func TestSVGRoundTrip(t *testing.T) {
 	}
 
 }
+
+// TestSVGClosed checks that closed paths are written with a
+// closepath, and that they're still closed when read back.
+func TestSVGClosed(t *testing.T) {
+	ps := &Paths{
+		Bounds: Bounds{Max: Vec2{100, 100}},
+		P: []Path{
+			{V: []Vec2{{10, 10}, {50, 10}, {50, 50}, {10, 10}}, Closed: true},
+			{V: []Vec2{{60, 60}, {90, 60}, {60, 60}}},
+			Circle(Vec2{30, 70}, 10),
+		},
+	}
+	var bb bytes.Buffer
+	if err := ps.SVG(&bb); err != nil {
+		t.Fatalf("failed to write svg: %v", err)
+	}
+	if got := strings.Count(bb.String(), "Z"); got != 2 {
+		t.Errorf("svg has %d closepaths, want 2:\n%s", got, bb.String())
+	}
+	got, err := FromSVG(&bb)
+	if err != nil {
+		t.Fatalf("failed to parse svg: %v", err)
+	}
+	if !curvesNear(got.P, ps.P, 1e-3) {
+		t.Errorf("svg round trip gave %v, want %v", got.P, ps.P)
+	}
+	for i := range ps.P {
+		if got.P[i].Closed != ps.P[i].Closed {
+			t.Errorf("path %d has Closed %v after round trip, want %v", i, got.P[i].Closed, ps.P[i].Closed)
+		}
+	}
+}
+
+type viewBoxTestCase struct {
+	desc       string
+	svg        string
+	wantBounds Bounds
+	want       []Path
+}
+
+func TestSVGViewBox(t *testing.T) {
+	cases := []viewBoxTestCase{
+		{
+			desc:       "no view box",
+			svg:        `<svg width="200mm" height="100mm"><path d="M 10 20 30 40"/></svg>`,
+			wantBounds: Bounds{Max: Vec2{200, 100}},
+			want:       []Path{{V: []Vec2{{10 * mmPerPx, 20 * mmPerPx}, {30 * mmPerPx, 40 * mmPerPx}}}},
+		},
+		{
+			desc:       "view box scale and offset",
+			svg:        `<svg width="200mm" height="100mm" viewBox="10 10 20 10"><path d="M 10 10 30 20"/></svg>`,
+			wantBounds: Bounds{Max: Vec2{200, 100}},
+			want:       []Path{{V: []Vec2{{0, 0}, {200, 100}}}},
+		},
+		{
+			desc:       "size from view box",
+			svg:        `<svg viewBox="0 0 20 10"><path d="M 1 2 3 4"/></svg>`,
+			wantBounds: Bounds{Max: Vec2{20, 10}},
+			want:       []Path{{V: []Vec2{{1, 2}, {3, 4}}}},
+		},
+		{
+			desc:       "size in mm from view box",
+			svg:        `<svg width="20mm" height="10mm" viewBox="0 0 20 10"><path d="M 1 2 3 4"/></svg>`,
+			wantBounds: Bounds{Max: Vec2{20, 10}},
+			want:       []Path{{V: []Vec2{{1, 2}, {3, 4}}}},
+		},
+		{
+			desc:       "meet centers",
+			svg:        `<svg width="200mm" height="100mm" viewBox="0 0 10 10"><path d="M 0 0 10 10"/></svg>`,
+			wantBounds: Bounds{Max: Vec2{200, 100}},
+			want:       []Path{{V: []Vec2{{50, 0}, {150, 100}}}},
+		},
+		{
+			desc:       "meet xMaxYMin",
+			svg:        `<svg width="200mm" height="100mm" viewBox="0 0 10 10" preserveAspectRatio="xMaxYMin meet"><path d="M 0 0 10 10"/></svg>`,
+			wantBounds: Bounds{Max: Vec2{200, 100}},
+			want:       []Path{{V: []Vec2{{100, 0}, {200, 100}}}},
+		},
+		{
+			desc:       "slice xMidYMax",
+			svg:        `<svg width="200mm" height="100mm" viewBox="0 0 10 10" preserveAspectRatio="xMidYMax slice"><path d="M 0 0 10 10"/></svg>`,
+			wantBounds: Bounds{Max: Vec2{200, 100}},
+			want:       []Path{{V: []Vec2{{0, -100}, {200, 100}}}},
+		},
+		{
+			desc:       "none stretches",
+			svg:        `<svg width="200mm" height="100mm" viewBox="0 0 10 10" preserveAspectRatio="none"><path d="M 0 0 10 10"/></svg>`,
+			wantBounds: Bounds{Max: Vec2{200, 100}},
+			want:       []Path{{V: []Vec2{{0, 0}, {200, 100}}}},
+		},
+		{
+			desc: "nested svg",
+			svg: `<svg width="200mm" height="100mm" viewBox="0 0 200 100">
+				<svg x="50%" y="50%" width="50%" height="50%" viewBox="0 0 2 1"><path d="M 0 0 2 1"/></svg>
+			</svg>`,
+			wantBounds: Bounds{Max: Vec2{200, 100}},
+			want:       []Path{{V: []Vec2{{100, 50}, {200, 100}}}},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			got, err := FromSVG(strings.NewReader(tc.svg))
+			if err != nil {
+				t.Fatalf("failed to parse svg: %v", err)
+			}
+			if want := (&Paths{Bounds: tc.wantBounds, P: tc.want}); !pathsNear(got, want) {
+				t.Errorf("got %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestParseLength(t *testing.T) {
+	cases := []struct {
+		s    string
+		ref  float64
+		want float64
+	}{
+		{"12", 0, 12},
+		{"12px", 0, 12},
+		{"-1.5e2", 0, -150},
+		{"+.5E+1px", 0, 5},
+		{"1in", 0, 96},
+		{"2.54cm", 0, 96},
+		{"25.4mm", 0, 96},
+		{"101.6Q", 0, 96},
+		{"72pt", 0, 96},
+		{"6pc", 0, 96},
+		{"2em", 0, 32},
+		{"1e1em", 0, 160},
+		{"3ex", 0, 24},
+		{"50%", 300, 150},
+		{" 7mm ", 0, 7 / mmPerPx},
+	}
+	for _, tc := range cases {
+		got, err := parseLength(tc.s, tc.ref)
+		if err != nil {
+			t.Errorf("parseLength(%q, %v) failed: %v", tc.s, tc.ref, err)
+			continue
+		}
+		if math.Abs(got-tc.want) > 1e-9 {
+			t.Errorf("parseLength(%q, %v) = %v, want %v", tc.s, tc.ref, got, tc.want)
+		}
+	}
+	for _, bad := range []string{"", "mm", "12furlongs", "1.2.3", "e5", "--1"} {
+		if got, err := parseLength(bad, 100); err == nil {
+			t.Errorf("parseLength(%q) = %v, want error", bad, got)
+		}
+	}
+}
+
+// pathFromD parses a single svg path element with the given path data,
+// in an svg document whose user units are millimeters.
+func pathFromD(t *testing.T, d string) *Paths {
+	t.Helper()
+	svg := `<svg width="100mm" height="100mm" viewBox="0 0 100 100"><path d="` + d + `"/></svg>`
+	ps, err := FromSVG(strings.NewReader(svg))
+	if err != nil {
+		t.Fatalf("failed to parse path %q: %v", d, err)
+	}
+	// Curves are flattened so the paths can be compared point by point.
+	ps.Flatten(0.01)
+	return ps
+}
+
+func TestSVGPathCommandsEquivalent(t *testing.T) {
+	cases := []struct {
+		d1, d2 string
+	}{
+		{"M 10 10 H 50 V 30 h -20 v 10", "M 10 10 L 50 10 50 30 30 30 30 40"},
+		{"M 10 10 C 20 0 30 0 40 10 S 60 20 70 10", "M 10 10 C 20 0 30 0 40 10 C 50 20 60 20 70 10"},
+		{"M 10 10 c 10 -10 20 -10 30 0 s 20 10 30 0", "M 10 10 C 20 0 30 0 40 10 C 50 20 60 20 70 10"},
+		{"M 10 10 S 30 0 40 10", "M 10 10 C 10 10 30 0 40 10"},
+		{"M 10 10 Q 25 0 40 10 T 70 10", "M 10 10 Q 25 0 40 10 Q 55 20 70 10"},
+		{"M 10 10 q 15 -10 30 0 t 30 0", "M 10 10 Q 25 0 40 10 Q 55 20 70 10"},
+		{"M 10 10 Q 40 10 70 10", "M 10 10 C 30 10 50 10 70 10"},
+		{"M 10 10 A 0 5 0 0 1 40 10", "M 10 10 L 40 10"},
+		{"M 10 10 h 10 z m 5 5 h 10 z", "M 10 10 20 10 Z M 15 15 25 15 Z"},
+	}
+	for _, tc := range cases {
+		got1 := pathFromD(t, tc.d1)
+		got2 := pathFromD(t, tc.d2)
+		got1.Simplify(1e-6)
+		got2.Simplify(1e-6)
+		if !pathsNear(got1, got2) {
+			t.Errorf("path %q gives %v, path %q gives %v", tc.d1, got1.P, tc.d2, got2.P)
+		}
+	}
+}
+
+func TestSVGArc(t *testing.T) {
+	cases := []struct {
+		desc   string
+		d      string
+		center Vec2
+		r      float64
+		want   Vec2 // a point the arc should pass near
+	}{
+		{"small sweep", "M 60 50 A 10 10 0 0 1 50 60", Vec2{50, 50}, 10, Vec2{50 + 10/math.Sqrt2, 50 + 10/math.Sqrt2}},
+		{"small no sweep", "M 60 50 A 10 10 0 0 0 50 60", Vec2{60, 60}, 10, Vec2{60 - 10/math.Sqrt2, 60 - 10/math.Sqrt2}},
+		{"large sweep", "M 60 50 A 10 10 0 1 1 50 60", Vec2{60, 60}, 10, Vec2{70, 60}},
+		{"radius too small", "M 40 50 a 1 1 0 0 0 20 0", Vec2{50, 50}, 10, Vec2{50, 60}},
+		{"rotated ellipse as circle", "M 40 50 A 10 10 45 0 0 60 50", Vec2{50, 50}, 10, Vec2{50, 60}},
+	}
+	for _, tc := range cases {
+		ps := pathFromD(t, tc.d)
+		if len(ps.P) != 1 {
+			t.Fatalf("%s: got %d paths, want 1", tc.desc, len(ps.P))
+		}
+		near := false
+		for _, v := range ps.P[0].V {
+			if d := v.Dist(tc.center); math.Abs(d-tc.r) > 0.05 {
+				t.Errorf("%s: point %v is distance %v from %v, want %v", tc.desc, v, d, tc.center, tc.r)
+			}
+			if v.Dist(tc.want) < 1 {
+				near = true
+			}
+		}
+		if !near {
+			t.Errorf("%s: arc %v doesn't pass near %v", tc.desc, ps.P[0].V, tc.want)
+		}
+	}
+}
+
+func TestSVGKeepsCurves(t *testing.T) {
+	svg := `<svg width="100mm" height="100mm" viewBox="0 0 100 100">
+		<path d="M 10 10 C 20 0 30 0 40 10 Q 50 20 60 10 A 20 10 30 1 0 80 40 L 90 90"/>
+		<ellipse cx="50" cy="50" rx="20" ry="10" transform="rotate(20)"/>
+		<rect x="10" y="60" width="30" height="20" rx="5"/>
+	</svg>`
+	got, err := FromSVG(strings.NewReader(svg))
+	if err != nil {
+		t.Fatalf("failed to parse svg: %v", err)
+	}
+	var kinds []SegmentKind
+	for _, s := range got.P[0].S {
+		kinds = append(kinds, s.Kind)
+	}
+	if want := []SegmentKind{CubicSegment, QuadSegment, ArcSegment, LineSegment}; !reflect.DeepEqual(kinds, want) {
+		t.Errorf("path has segments of kinds %v, want %v", kinds, want)
+	}
+
+	// Curves are written back to svg as curves.
+	var bb bytes.Buffer
+	if err := got.SVG(&bb); err != nil {
+		t.Fatalf("failed to write back svg: %v", err)
+	}
+	got2, err := FromSVG(&bb)
+	if err != nil {
+		t.Fatalf("failed to re-parse svg: %v", err)
+	}
+	if !curvesNear(got.P, got2.P, 1e-3) {
+		t.Errorf("svg round-trip changed curves. Started with:\n%v\nGot:\n%v", got.P, got2.P)
+	}
+}
+
+func TestSVGShapes(t *testing.T) {
+	cases := []struct {
+		desc  string
+		shape string
+		want  []Path
+	}{
+		{
+			desc:  "rect",
+			shape: `<rect x="10" y="20" width="30" height="40"/>`,
+			want:  []Path{{V: []Vec2{{10, 20}, {40, 20}, {40, 60}, {10, 60}, {10, 20}}, Closed: true}},
+		},
+		{
+			desc:  "empty rect",
+			shape: `<rect x="10" y="20" width="0" height="40"/>`,
+			want:  nil,
+		},
+		{
+			desc:  "polyline",
+			shape: `<polyline points="10,20 30,40,50 60 1e1-5"/>`,
+			want:  []Path{{V: []Vec2{{10, 20}, {30, 40}, {50, 60}, {10, -5}}}},
+		},
+		{
+			desc:  "polygon",
+			shape: `<polygon points="10 20 30 40 50 60"/>`,
+			want:  []Path{{V: []Vec2{{10, 20}, {30, 40}, {50, 60}, {10, 20}}, Closed: true}},
+		},
+		{
+			desc:  "transformed rect",
+			shape: `<g transform="translate(5, 5)"><rect width="10" height="10"/></g>`,
+			want:  []Path{{V: []Vec2{{5, 5}, {15, 5}, {15, 15}, {5, 15}, {5, 5}}, Closed: true}},
+		},
+	}
+	for _, tc := range cases {
+		svg := `<svg width="100mm" height="100mm" viewBox="0 0 100 100">` + tc.shape + `</svg>`
+		got, err := FromSVG(strings.NewReader(svg))
+		if err != nil {
+			t.Fatalf("%s: failed to parse svg: %v", tc.desc, err)
+		}
+		want := &Paths{Bounds: got.Bounds, P: tc.want}
+		if !pathsNear(got, want) {
+			t.Errorf("%s: got %v, want %v", tc.desc, got.P, tc.want)
+		}
+	}
+}
+
+func TestSVGCurvedShapes(t *testing.T) {
+	cases := []struct {
+		desc   string
+		shape  string
+		onEdge func(v Vec2) bool
+		bounds Bounds
+	}{
+		{
+			desc:  "circle",
+			shape: `<circle cx="50" cy="40" r="20"/>`,
+			onEdge: func(v Vec2) bool {
+				return math.Abs(v.Dist(Vec2{50, 40})-20) < 0.01
+			},
+			bounds: Bounds{Min: Vec2{30, 20}, Max: Vec2{70, 60}},
+		},
+		{
+			desc:  "ellipse",
+			shape: `<ellipse cx="50" cy="40" rx="20" ry="10"/>`,
+			onEdge: func(v Vec2) bool {
+				dx, dy := (v[0]-50)/20, (v[1]-40)/10
+				return math.Abs(dx*dx+dy*dy-1) < 0.01
+			},
+			bounds: Bounds{Min: Vec2{30, 30}, Max: Vec2{70, 50}},
+		},
+		{
+			desc:  "rounded rect",
+			shape: `<rect x="10" y="10" width="40" height="20" rx="5"/>`,
+			onEdge: func(v Vec2) bool {
+				// Clamp to the inner rectangle of corner centers,
+				// and check distance from that.
+				c := Vec2{math.Min(math.Max(v[0], 15), 45), math.Min(math.Max(v[1], 15), 25)}
+				return math.Abs(v.Dist(c)-5) < 0.01
+			},
+			bounds: Bounds{Min: Vec2{10, 10}, Max: Vec2{50, 30}},
+		},
+	}
+	for _, tc := range cases {
+		svg := `<svg width="100mm" height="100mm" viewBox="0 0 100 100">` + tc.shape + `</svg>`
+		got, err := FromSVG(strings.NewReader(svg))
+		if err != nil {
+			t.Fatalf("%s: failed to parse svg: %v", tc.desc, err)
+		}
+		if len(got.P) != 1 {
+			t.Fatalf("%s: got %d paths, want 1", tc.desc, len(got.P))
+		}
+		vs := got.P[0].V
+		if vs[0].Dist(vs[len(vs)-1]) > 1e-9 {
+			t.Errorf("%s: path is not closed: starts at %v, ends at %v", tc.desc, vs[0], vs[len(vs)-1])
+		}
+		for _, v := range vs {
+			if !tc.onEdge(v) {
+				t.Errorf("%s: vertex %v is not on the shape's outline", tc.desc, v)
+			}
+		}
+		got.TightenBounds()
+		if !pathsNear(&Paths{Bounds: got.Bounds}, &Paths{Bounds: tc.bounds}) {
+			t.Errorf("%s: got bounds %v, want %v", tc.desc, got.Bounds, tc.bounds)
+		}
+	}
+}
+
+func TestSVGUse(t *testing.T) {
+	cases := []struct {
+		desc string
+		body string
+		want []Path
+	}{
+		{
+			desc: "defs not drawn",
+			body: `<defs><path id="a" d="M 0 0 10 0"/></defs>`,
+			want: nil,
+		},
+		{
+			desc: "use with x, y",
+			body: `<defs><path id="a" d="M 0 0 10 0"/></defs><use href="#a" x="5" y="6"/>`,
+			want: []Path{{V: []Vec2{{5, 6}, {15, 6}}}},
+		},
+		{
+			desc: "xlink href and transform",
+			body: `<defs><path id="a" d="M 0 0 10 0"/></defs><use xlink:href="#a" transform="scale(2)" x="1"/>`,
+			want: []Path{{V: []Vec2{{2, 0}, {22, 0}}}},
+		},
+		{
+			desc: "use of a group inside a group",
+			body: `<g id="g1"><path d="M 0 0 10 0"/></g><g transform="translate(0, 10)"><use href="#g1"/></g>`,
+			want: []Path{{V: []Vec2{{0, 0}, {10, 0}}}, {V: []Vec2{{0, 10}, {10, 10}}}},
+		},
+		{
+			desc: "symbol with viewBox",
+			body: `<symbol id="s" viewBox="0 0 1 1"><path d="M 0 0 1 1"/></symbol><use href="#s" x="10" y="20" width="30" height="30"/>`,
+			want: []Path{{V: []Vec2{{10, 20}, {40, 50}}}},
+		},
+		{
+			desc: "nested use",
+			body: `<defs><path id="a" d="M 0 0 10 0"/><use id="b" href="#a" y="1"/></defs><use href="#b" y="2"/>`,
+			want: []Path{{V: []Vec2{{0, 3}, {10, 3}}}},
+		},
+	}
+	for _, tc := range cases {
+		svg := `<svg width="100mm" height="100mm" viewBox="0 0 100 100" xmlns:xlink="http://www.w3.org/1999/xlink">` + tc.body + `</svg>`
+		got, err := FromSVG(strings.NewReader(svg))
+		if err != nil {
+			t.Fatalf("%s: failed to parse svg: %v", tc.desc, err)
+		}
+		want := &Paths{Bounds: got.Bounds, P: tc.want}
+		if !pathsNear(got, want) {
+			t.Errorf("%s: got %v, want %v", tc.desc, got.P, tc.want)
+		}
+	}
+}
+
+func TestSVGUseCycle(t *testing.T) {
+	svg := `<svg width="100mm" height="100mm"><g id="a"><use href="#b"/></g><g id="b"><use href="#a"/></g></svg>`
+	if _, err := FromSVG(strings.NewReader(svg)); err == nil {
+		t.Errorf("expected error parsing svg with a use cycle")
+	}
+}
+
+func TestSVGIDsInDefs(t *testing.T) {
+	svg := `<svg width="100mm" height="100mm" viewBox="0 0 100 100">
+		<defs><g id="glyph"><path d="M 0 0 10 0"/></g></defs>
+		<path id="other" d="M 0 5 10 5"/>
+		<use href="#glyph" y="20"/>
+	</svg>`
+	pm, err := IDsFromSVG(strings.NewReader(svg), []string{"glyph", "other"})
+	if err != nil {
+		t.Fatalf("failed to parse svg: %v", err)
+	}
+	want := map[string][]Path{
+		"":      {{V: []Vec2{{0, 20}, {10, 20}}}},
+		"glyph": {{V: []Vec2{{0, 0}, {10, 0}}}},
+		"other": {{V: []Vec2{{0, 5}, {10, 5}}}},
+	}
+	for id, w := range want {
+		if !pathsNear(pm[id], &Paths{Bounds: pm[id].Bounds, P: w}) {
+			t.Errorf("id %q: got %v, want %v", id, pm[id].P, w)
+		}
+	}
+}
+
+func TestSVGHidden(t *testing.T) {
+	svg := `<svg width="100mm" height="100mm" viewBox="0 0 100 100">
+		<path d="M 0 0 1 0"/>
+		<path display="none" d="M 0 1 1 1"/>
+		<g style="display:none"><path d="M 0 2 1 2"/></g>
+		<path visibility="hidden" d="M 0 3 1 3"/>
+		<g visibility="hidden">
+			<path d="M 0 4 1 4"/>
+			<path visibility="visible" d="M 0 5 1 5"/>
+		</g>
+		<path stroke="none" d="M 0 6 1 6"/>
+		<g stroke="none"><path stroke="red" d="M 0 7 1 7"/></g>
+		<defs><path id="p" d="M 0 8 1 8"/></defs>
+		<use href="#p" display="none"/>
+		<use href="#p" y="1"/>
+	</svg>`
+	got, err := FromSVG(strings.NewReader(svg))
+	if err != nil {
+		t.Fatalf("failed to parse svg: %v", err)
+	}
+	var ys []float64
+	for _, p := range got.P {
+		ys = append(ys, p.V[0][1])
+	}
+	want := []float64{0, 5, 7, 9}
+	if !reflect.DeepEqual(ys, want) {
+		t.Errorf("got paths at y=%v, want %v", ys, want)
+	}
+}
+
+func TestInkscapeLayersFromSVG(t *testing.T) {
+	svg := `<svg width="100mm" height="100mm" viewBox="0 0 100 100"
+			xmlns:inkscape="http://www.inkscape.org/namespaces/inkscape">
+		<path d="M 0 0 1 0"/>
+		<g inkscape:groupmode="layer" inkscape:label="Outline" id="layer1">
+			<path d="M 0 1 1 1"/>
+			<g inkscape:groupmode="layer" inkscape:label="Details"><path d="M 0 2 1 2"/></g>
+			<path d="M 0 3 1 3"/>
+		</g>
+		<g inkscape:groupmode="layer" inkscape:label="Hidden" style="display:none"><path d="M 0 4 1 4"/></g>
+		<g inkscape:groupmode="layer" id="layer4"><g><path d="M 0 5 1 5"/></g></g>
+	</svg>`
+	layers, err := InkscapeLayersFromSVG(strings.NewReader(svg))
+	if err != nil {
+		t.Fatalf("failed to parse svg: %v", err)
+	}
+	var got []string
+	for _, l := range layers {
+		var ys []string
+		for _, p := range l.Paths.P {
+			ys = append(ys, fmt.Sprint(p.V[0][1]))
+		}
+		got = append(got, l.Name+":"+strings.Join(ys, ","))
+	}
+	want := []string{":0", "Outline:1,3", "Details:2", "layer4:5"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got layers %v, want %v", got, want)
+	}
+}
+
+func TestSVGText(t *testing.T) {
+	// A font with a single vertical stroke for I, 7 units high,
+	// so that its font size is 10 units.
+	font := &Font{
+		LineAdvance: 10,
+		Glyph: map[rune]*FontGlyph{
+			'I': {Height: 7, Advance: 2, Paths: &Paths{P: []Path{{V: []Vec2{{0, 0}, {0, -7}}}}}},
+			' ': {Advance: 3, Paths: &Paths{}},
+		},
+	}
+	testCases := []struct {
+		text string
+		want [][]Vec2
+	}{
+		{`<text x="10" y="20" font-size="10">II</text>`,
+			[][]Vec2{{{10, 20}, {10, 13}}, {{12, 20}, {12, 13}}}},
+		{`<text x="10" y="20" font-size="20">I</text>`,
+			[][]Vec2{{{10, 20}, {10, 6}}}},
+		{`<text x="10" y="20" font-size="10">I  I</text>`,
+			[][]Vec2{{{10, 20}, {10, 13}}, {{15, 20}, {15, 13}}}},
+		{`<text x="10" y="20" font-size="10" text-anchor="middle">II</text>`,
+			[][]Vec2{{{8, 20}, {8, 13}}, {{10, 20}, {10, 13}}}},
+		{`<text x="10" y="20" font-size="10" text-anchor="end">II</text>`,
+			[][]Vec2{{{6, 20}, {6, 13}}, {{8, 20}, {8, 13}}}},
+		{`<text x="10" y="20" font-size="10" transform="translate(5 5)">I?</text>`,
+			[][]Vec2{{{15, 25}, {15, 18}}}},
+		{`<text x="10" y="20" font-size="10">I <tspan font-size="20">I</tspan><tspan x="50" dy="10">I</tspan></text>`,
+			[][]Vec2{{{10, 20}, {10, 13}}, {{15, 20}, {15, 6}}, {{50, 30}, {50, 23}}}},
+		{`<text x="10" y="20" font-size="10">I <tspan font-size="20">I</tspan> I</text>`,
+			[][]Vec2{{{10, 20}, {10, 13}}, {{15, 20}, {15, 6}}, {{22, 20}, {22, 13}}}},
+		{`<text x="10" y="20" font-size="10"><tspan>I</tspan> <tspan>I</tspan></text>`,
+			[][]Vec2{{{10, 20}, {10, 13}}, {{15, 20}, {15, 13}}}},
+		{`<g style="font-size:10px"><text x="10" y="20">I</text></g>`,
+			[][]Vec2{{{10, 20}, {10, 13}}}},
+		{`<text x="10" y="20" font-size="10" fill="none">I</text>`,
+			nil},
+	}
+	for _, tc := range testCases {
+		svg := `<svg width="100mm" height="100mm" viewBox="0 0 100 100">` + tc.text + `</svg>`
+		ps, err := FromSVGWithFont(strings.NewReader(svg), font)
+		if err != nil {
+			t.Errorf("%s: failed to parse: %v", tc.text, err)
+			continue
+		}
+		want := &Paths{Bounds: ps.Bounds}
+		for _, vs := range tc.want {
+			want.P = append(want.P, Path{V: vs})
+		}
+		if !pathsNear(ps, want) {
+			t.Errorf("%s: got %v, want %v", tc.text, ps.P, want.P)
+		}
+	}
+}
+
+func TestSVGClipPath(t *testing.T) {
+	defs := `<defs>
+		<clipPath id="c"><rect x="10" y="0" width="20" height="100"/></clipPath>
+		<clipPath id="c2" clip-path="url(#c)"><rect x="20" y="0" width="80" height="100"/></clipPath>
+		<clipPath id="bb" clipPathUnits="objectBoundingBox"><rect x="0.25" y="0" width="0.5" height="1"/></clipPath>
+		<clipPath id="eo" clip-rule="evenodd"><path d="M 0 0 H 100 V 100 H 0 Z M 40 40 H 60 V 60 H 40 Z"/></clipPath>
+		<clipPath id="nz"><path d="M 0 0 H 100 V 100 H 0 Z M 40 40 H 60 V 60 H 40 Z"/></clipPath>
+		<clipPath id="empty"></clipPath>
+		<mask id="m" maskUnits="userSpaceOnUse">
+			<rect x="0" y="0" width="50" height="100" fill="white"/>
+			<rect x="20" y="0" width="10" height="100" fill="black"/>
+		</mask>
+		<mask id="half" maskContentUnits="objectBoundingBox">
+			<rect x="0" y="0" width="0.5" height="1" fill="#eee"/>
+		</mask>
+	</defs>`
+	testCases := []struct {
+		svg  string
+		want [][]Vec2
+	}{
+		{`<path d="M -10 50 L 110 50" clip-path="url(#c)"/>`,
+			[][]Vec2{{{10, 50}, {30, 50}}}},
+		{`<path d="M -10 50 L 110 50" style="clip-path: url('#c')"/>`,
+			[][]Vec2{{{10, 50}, {30, 50}}}},
+		{`<g clip-path="url(#c)"><path d="M -10 50 L 110 50"/><path d="M 20 60 L 20 200"/></g>`,
+			[][]Vec2{{{10, 50}, {30, 50}}, {{20, 60}, {20, 100}}}},
+		{`<path d="M -10 50 L 110 50" clip-path="url(#c2)"/>`,
+			[][]Vec2{{{20, 50}, {30, 50}}}},
+		{`<g clip-path="url(#c)"><path d="M -10 50 L 110 50" clip-path="url(#c2)"/></g>`,
+			[][]Vec2{{{20, 50}, {30, 50}}}},
+		{`<path d="M 20 40 L 60 60" clip-path="url(#bb)"/>`,
+			[][]Vec2{{{30, 45}, {50, 55}}}},
+		{`<path d="M -10 50 L 110 50" clip-path="url(#eo)"/>`,
+			[][]Vec2{{{0, 50}, {40, 50}}, {{60, 50}, {100, 50}}}},
+		{`<path d="M -10 50 L 110 50" clip-path="url(#nz)"/>`,
+			[][]Vec2{{{0, 50}, {100, 50}}}},
+		{`<path d="M -10 50 L 110 50" clip-path="url(#empty)"/>`,
+			nil},
+		{`<path d="M -10 50 L 110 50" transform="translate(5 0)" clip-path="url(#c)"/>`,
+			[][]Vec2{{{15, 50}, {35, 50}}}},
+		{`<path d="M -10 50 L 110 50" mask="url(#m)"/>`,
+			[][]Vec2{{{0, 50}, {20, 50}}, {{30, 50}, {50, 50}}}},
+		{`<path d="M 0 20 L 100 80" mask="url(#half)"/>`,
+			[][]Vec2{{{0, 20}, {50, 50}}}},
+		{`<path d="M 0 10 L 50 10 L 50 90 L 0 90 Z" clip-path="url(#c)"/>`,
+			[][]Vec2{{{10, 10}, {30, 10}}, {{30, 90}, {10, 90}}}},
+		{`<path d="M 20 10 L 50 10 L 50 90 L 0 90 L 0 10 Z" clip-path="url(#c)"/>`,
+			[][]Vec2{{{10, 10}, {20, 10}, {30, 10}}, {{30, 90}, {10, 90}}}},
+	}
+	for _, tc := range testCases {
+		svg := `<svg width="100mm" height="100mm" viewBox="0 0 100 100">` + defs + tc.svg + `</svg>`
+		ps, err := FromSVG(strings.NewReader(svg))
+		if err != nil {
+			t.Errorf("%s: failed to parse: %v", tc.svg, err)
+			continue
+		}
+		want := &Paths{Bounds: ps.Bounds}
+		for _, vs := range tc.want {
+			want.P = append(want.P, Path{V: vs})
+		}
+		if !pathsNear(ps, want) {
+			t.Errorf("%s: got %v, want %v", tc.svg, ps.P, want.P)
+		}
+	}
+}
+
+func TestSVGClipPathCycle(t *testing.T) {
+	svg := `<svg width="100mm" height="100mm" viewBox="0 0 100 100">
+		<clipPath id="a" clip-path="url(#a)"><rect width="50" height="50"/></clipPath>
+		<path d="M 0 10 L 100 10" clip-path="url(#a)"/>
+	</svg>`
+	if _, err := FromSVG(strings.NewReader(svg)); err == nil {
+		t.Errorf("expected error from self-referencing clipPath")
+	}
+}
+
+func TestFromSVGWithOptions(t *testing.T) {
+	svg := `<svg width="100mm" height="50mm" viewBox="0 0 100 50">
+		<foo id="f1"/>
+		<text>hello</text>
+		<use href="#missing"/>
+		<path d="M 0 0 L 10 0" clip-path="url(#nope)"/>
+		<circle cx="50" cy="25" r="20"/>
+	</svg>`
+	ps, warnings, err := FromSVGWithOptions(strings.NewReader(svg), nil)
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+	var got []string
+	for _, w := range warnings {
+		got = append(got, w.Element+"/"+w.ID)
+	}
+	want := []string{"foo/f1", "text/", "use/", "path/"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got warnings %v, want %v", got, want)
+	}
+	if len(ps.P) != 2 {
+		t.Errorf("got %d paths, want 2", len(ps.P))
+	}
+
+	_, _, err = FromSVGWithOptions(strings.NewReader(svg), &ParseOptions{Strict: true})
+	if w, ok := err.(*Warning); !ok || w.Element != "foo" || w.ID != "f1" {
+		t.Errorf("strict mode: got error %v, want a warning about element foo", err)
+	}
+
+	ps, _, err = FromSVGWithOptions(strings.NewReader(svg), &ParseOptions{Unit: "in"})
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+	wantBounds := Bounds{Max: Vec2{100 / 25.4, 50 / 25.4}}
+	if math.Abs(ps.Bounds.Max[0]-wantBounds.Max[0]) > 1e-9 || math.Abs(ps.Bounds.Max[1]-wantBounds.Max[1]) > 1e-9 {
+		t.Errorf("in inches, got bounds %v, want %v", ps.Bounds, wantBounds)
+	}
+	// By default, the paths are in the document's own units.
+	px := `<svg width="200" height="100"><path d="M 10 20 30 40"/></svg>`
+	for _, tc := range []struct {
+		unit  string
+		scale float64
+	}{{"", 1}, {"px", 1}, {"mm", mmPerPx}} {
+		ps, _, err := FromSVGWithOptions(strings.NewReader(px), &ParseOptions{Unit: tc.unit})
+		if err != nil {
+			t.Fatalf("failed to parse: %v", err)
+		}
+		want := &Paths{Bounds: Bounds{Max: Vec2{200 * tc.scale, 100 * tc.scale}}, P: []Path{{V: []Vec2{{10 * tc.scale, 20 * tc.scale}, {30 * tc.scale, 40 * tc.scale}}}}}
+		if !pathsNear(ps, want) {
+			t.Errorf("with unit %q, got %v, want %v", tc.unit, ps, want)
+		}
+	}
+	// If the width and height are in different units, both are in mm.
+	mixed := `<svg width="10cm" height="100mm" viewBox="0 0 100 100"><path d="M 0 0 100 100"/></svg>`
+	ps, _, err = FromSVGWithOptions(strings.NewReader(mixed), nil)
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+	wantMixed := &Paths{Bounds: Bounds{Max: Vec2{100, 100}}, P: []Path{{V: []Vec2{{0, 0}, {100, 100}}}}}
+	if !pathsNear(ps, wantMixed) {
+		t.Errorf("with mixed units, got %v, want %v", ps, wantMixed)
+	}
+	if _, _, err := FromSVGWithOptions(strings.NewReader(svg), &ParseOptions{Unit: "furlong"}); err == nil {
+		t.Errorf("expected error from unknown unit")
+	}
+
+	coarse, _, err := FromSVGWithOptions(strings.NewReader(svg), &ParseOptions{Tolerance: 5})
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+	fine, _, err := FromSVGWithOptions(strings.NewReader(svg), &ParseOptions{Tolerance: 0.05})
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+	if nc, nf := len(coarse.P[1].V), len(fine.P[1].V); nc >= nf {
+		t.Errorf("circle has %d vertices with a coarse tolerance, and %d with a fine one", nc, nf)
+	}
+}
+
+// TestSVGStrictInkscape checks that strict mode accepts the extra
+// elements that Inkscape writes, and elements that aren't rendered.
+func TestSVGStrictInkscape(t *testing.T) {
+	svg := `<svg xmlns="http://www.w3.org/2000/svg" xmlns:inkscape="http://www.inkscape.org/namespaces/inkscape"
+			xmlns:sodipodi="http://sodipodi.sourceforge.net/DTD/sodipodi-0.dtd" width="100" height="100">
+		<defs><inkscape:perspective id="p"/><foo/></defs>
+		<sodipodi:namedview id="v"><inkscape:grid id="g"/></sodipodi:namedview>
+		<inkscape:clipboard/>
+		<path d="M 0 0 L 10 0"/>
+	</svg>`
+	ps, warnings, err := FromSVGWithOptions(strings.NewReader(svg), &ParseOptions{Strict: true})
+	if err != nil {
+		t.Fatalf("strict mode failed: %v", err)
+	}
+	if len(warnings) != 0 || len(ps.P) != 1 {
+		t.Errorf("got %d paths and warnings %v, want 1 path and no warnings", len(ps.P), warnings)
+	}
+
+	// Unsupported svg elements are still reported where they're drawn.
+	svg = strings.Replace(svg, "<inkscape:clipboard/>", "<foo/>", 1)
+	if _, _, err := FromSVGWithOptions(strings.NewReader(svg), &ParseOptions{Strict: true}); err == nil {
+		t.Errorf("strict mode accepted an unsupported element")
+	}
+}
+
+func TestSVGAttrs(t *testing.T) {
+	svg := `<svg width="100mm" height="100mm" viewBox="0 0 100 100"
+			xmlns:inkscape="http://www.inkscape.org/namespaces/inkscape">
+		<path id="plain" d="M 0 0 10 0"/>
+		<g inkscape:groupmode="layer" inkscape:label="Outline" stroke="red">
+			<rect id="box" x="10" y="10" width="20" height="20"/>
+			<g id="group"><line x1="0" y1="50" x2="10" y2="50" stroke="#00f"/></g>
+		</g>
+	</svg>`
+	got, err := FromSVG(strings.NewReader(svg))
+	if err != nil {
+		t.Fatalf("failed to parse svg: %v", err)
+	}
+	want := []Attrs{
+		{Color: "#000000", ID: "plain"},
+		{Layer: "Outline", Color: "#ff0000", ID: "box"},
+		{Layer: "Outline", Color: "#0000ff"},
+	}
+	check := func(desc string, ps *Paths, want []Attrs) {
+		if len(ps.P) != len(want) {
+			t.Fatalf("%s: got %d paths, want %d", desc, len(ps.P), len(want))
+		}
+		for i, p := range ps.P {
+			if p.Attrs != want[i] {
+				t.Errorf("%s: path %d has attributes %+v, want %+v", desc, i, p.Attrs, want[i])
+			}
+		}
+	}
+	check("parsed", got, want)
+
+	// Colours and layers are written out, but ids aren't, since
+	// a clipped path may be in many pieces.
+	var bb bytes.Buffer
+	if err := got.SVG(&bb); err != nil {
+		t.Fatalf("failed to write svg: %v", err)
+	}
+	again, err := FromSVG(&bb)
+	if err != nil {
+		t.Fatalf("failed to re-parse svg: %v", err)
+	}
+	for i := range want {
+		want[i].ID = ""
+	}
+	check("round trip", again, want)
+}
+
+func TestSVGFill(t *testing.T) {
+	svg := `<svg width="100mm" height="100mm" viewBox="0 0 100 100">
+		<rect x="10" y="10" width="20" height="10" fill="red" stroke="none"/>
+		<circle cx="50" cy="50" r="10" fill="none" stroke="blue"/>
+		<path d="M 60 10 h 30 v 30 h -30 Z M 70 20 h 10 v 10 h -10 Z" fill-rule="evenodd" fill="#0f0" stroke="#0f0"/>
+	</svg>`
+	ps, _, err := FromSVGWithOptions(strings.NewReader(svg), nil)
+	if err != nil {
+		t.Fatalf("failed to parse svg: %v", err)
+	}
+	if len(ps.P) != 3 {
+		t.Errorf("without hatching, got %d paths, want the 3 outlines", len(ps.P))
+	}
+
+	ps, _, err = FromSVGWithOptions(strings.NewReader(svg), &ParseOptions{Fill: &FillOptions{PenWidth: 0.5}})
+	if err != nil {
+		t.Fatalf("failed to parse svg: %v", err)
+	}
+	var red, green []Path
+	for _, p := range ps.P {
+		switch {
+		case p.Attrs.Color == "#ff0000":
+			red = append(red, p)
+		case p.Attrs.Color == "#00ff00" && !p.Closed:
+			green = append(green, p)
+		}
+	}
+	// The hatching is joined up into a single path for the
+	// rectangle, and a few for the square with a hole, and fills
+	// them.
+	if len(red) != 1 || len(green) < 2 || len(green) > 3 {
+		t.Fatalf("got %d red and %d green hatching paths, want 1 and 2 or 3", len(red), len(green))
+	}
+	rect := Bounds{Min: Vec2{10, 10}, Max: Vec2{30, 20}}
+	for _, v := range red[0].V {
+		if !rect.Expand(1e-9).Contains(v) {
+			t.Errorf("red hatching goes outside the rectangle, to %v", v)
+		}
+	}
+	if got, want := len(red[0].V), 2*10/0.5; float64(got) != want {
+		t.Errorf("red hatching has %d vertices, want %g", got, want)
+	}
+	hole := Bounds{Min: Vec2{70, 20}, Max: Vec2{80, 30}}
+	for _, p := range green {
+		for i := 1; i < len(p.V); i++ {
+			if m := p.V[i-1].Lerp(p.V[i], 0.5); hole.Expand(-1e-9).Contains(m) {
+				t.Errorf("green hatching goes through the hole, at %v", m)
+			}
+		}
+	}
+
+	// Fills are put in layers by their colour.
+	layers, _, err := ColorLayersFromSVGWithOptions(strings.NewReader(svg), &ParseOptions{Fill: &FillOptions{PenWidth: 0.5, Cross: true}})
+	if err != nil {
+		t.Fatalf("failed to parse svg: %v", err)
+	}
+	var names []string
+	for _, l := range layers {
+		names = append(names, l.Name)
+	}
+	if want := []string{"#ff0000", "#0000ff", "#00ff00"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("got colour layers %v, want %v", names, want)
+	}
+}
+
+func TestFillShading(t *testing.T) {
+	cases := []struct {
+		desc      string
+		fo        FillOptions
+		lightness float64
+		angles    []float64
+		spacing   float64
+	}{
+		{"black", FillOptions{PenWidth: 0.5}, 0, []float64{0}, 0.5},
+		{"grey", FillOptions{PenWidth: 0.5, Angle: 1}, 0.75, []float64{1}, 2},
+		{"white", FillOptions{PenWidth: 0.5}, 1, nil, 0},
+		{"cross-hatched", FillOptions{PenWidth: 0.5, Cross: true}, 0.75, []float64{0, math.Pi / 2}, 0.5 / (1 - math.Sqrt(0.75))},
+		{"light with layers", FillOptions{PenWidth: 0.5, Layers: 4}, 0.8, []float64{0}, 2.5},
+		{"dark with layers", FillOptions{PenWidth: 0.5, Layers: 4}, 0.3, []float64{0, math.Pi / 4, math.Pi / 2}, 0.5 / (1 - math.Cbrt(0.3))},
+		{"tone curve", FillOptions{PenWidth: 0.5, Tone: func(l float64) float64 { return 1 + 3*l }}, 0.5, []float64{0}, 2.5},
+		{"tone curve leaves empty", FillOptions{PenWidth: 0.5, Tone: func(l float64) float64 { return 0 }}, 0.5, nil, 0},
+		{"tone curve too dark", FillOptions{PenWidth: 0.5, Tone: func(l float64) float64 { return 0.1 }}, 0.5, []float64{0}, 0.5},
+	}
+	for _, c := range cases {
+		angles, spacing := c.fo.shading(c.lightness)
+		if !reflect.DeepEqual(angles, c.angles) || math.Abs(spacing-c.spacing) > 1e-9 {
+			t.Errorf("%s: shading is at angles %v with spacing %g, want %v and %g", c.desc, angles, spacing, c.angles, c.spacing)
+		}
+	}
+}
+
+func TestSVGShading(t *testing.T) {
+	svg := `<svg width="100mm" height="100mm" viewBox="0 0 100 100">
+		<rect x="0" y="0" width="20" height="10" fill="black" stroke="none"/>
+		<rect x="0" y="20" width="20" height="10" fill="#808080" stroke="none"/>
+		<rect x="0" y="40" width="20" height="10" fill="white" stroke="none"/>
+		<rect x="0" y="60" width="20" height="10" fill="black" fill-opacity="0.5" opacity="0.5" stroke="none"/>
+		<g opacity="0.5"><rect x="0" y="80" width="20" height="10" fill="black" fill-opacity="0.5" stroke="none"/></g>
+	</svg>`
+	ps, _, err := FromSVGWithOptions(strings.NewReader(svg), &ParseOptions{Fill: &FillOptions{PenWidth: 0.5, Shade: true}})
+	if err != nil {
+		t.Fatalf("failed to parse svg: %v", err)
+	}
+	// Each rectangle is hatched with a single path, going back and
+	// forth across it, with lines spaced by the darkness of its fill.
+	// The opacity of a group lightens the shapes in it.
+	lines := map[float64]int{}
+	for _, p := range ps.P {
+		lines[math.Floor(p.V[0][1]/20)*20] += len(p.V) / 2
+	}
+	want := map[float64]int{0: 20, 20: 10, 60: 5, 80: 5}
+	if !reflect.DeepEqual(lines, want) {
+		t.Errorf("got hatching lines %v for the rectangles at each y, want %v", lines, want)
+	}
+}
+
+func TestSVGFillPatterns(t *testing.T) {
+	svg := `<svg width="100mm" height="100mm" viewBox="0 0 100 100" xmlns:inkscape="http://www.inkscape.org/namespaces/inkscape">
+		<g inkscape:groupmode="layer" inkscape:label="Rings"><rect x="10" y="10" width="20" height="20" stroke="none"/></g>
+		<g inkscape:groupmode="layer" inkscape:label="Lines"><rect x="50" y="10" width="20" height="20" stroke="none"/></g>
+	</svg>`
+	opts := &ParseOptions{Fill: &FillOptions{
+		PenWidth:      1,
+		Pattern:       SpiralFill,
+		LayerPatterns: map[string]FillPattern{"Lines": HatchFill},
+	}}
+	layers, _, err := InkscapeLayersFromSVGWithOptions(strings.NewReader(svg), opts)
+	if err != nil {
+		t.Fatalf("failed to parse svg: %v", err)
+	}
+	if len(layers) != 2 {
+		t.Fatalf("got %d layers, want 2", len(layers))
+	}
+	// The first square is filled with a spiral, which goes round the
+	// square, and the second with hatching, which goes across it.
+	rings, lines := layers[0].Paths.P, layers[1].Paths.P
+	if len(rings) != 1 || len(lines) != 1 {
+		t.Fatalf("got %d paths filling the first square and %d the second, want 1 and 1", len(rings), len(lines))
+	}
+	inner := Bounds{Min: Vec2{10.5, 10.5}, Max: Vec2{29.5, 29.5}}
+	for _, v := range rings[0].V {
+		if !inner.Expand(1e-9).Contains(v) {
+			t.Errorf("spiral goes to %v, outside %v", v, inner)
+		}
+	}
+	if got := rings[0].V[0]; !inner.Expand(1e-9).Contains(got) || inner.Expand(-1e-9).Contains(got) {
+		t.Errorf("spiral starts at %v, want it on the edge of %v", got, inner)
+	}
+	if got, want := len(lines[0].V), 2*20; got != want {
+		t.Errorf("hatching has %d vertices, want %d", got, want)
+	}
+}
@@ -0,0 +1,262 @@
+package paths
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/JoshVarga/svgparser"
+)
+
+// svgShape is the outline of a shape found inside a clipPath or
+// mask, in output coordinates.
+type svgShape struct {
+	P     []Path
+	style svgStyle
+	clip  region // if set, the shape is clipped to this region
+}
+
+// area returns the region inside the shape, filled according to
+// the given fill-rule property.
+func (s *svgShape) area(ruleProp string) region {
+	rule := NonZero
+	if s.style[ruleProp] == "evenodd" {
+		rule = EvenOdd
+	}
+	return intersect(polygonArea(s.P, rule), s.clip)
+}
+
+// parseURLRef parses a reference like url(#id), returning the id.
+func parseURLRef(v string) (string, bool) {
+	if !strings.HasPrefix(v, "url(") || !strings.HasSuffix(v, ")") {
+		return "", false
+	}
+	v = strings.Trim(strings.TrimSpace(v[4:len(v)-1]), `"'`)
+	if !strings.HasPrefix(v, "#") {
+		return "", false
+	}
+	return v[1:], true
+}
+
+// referenced returns the element named by a clip-path or mask
+// property of c, which must be of the given type, or nil if
+// there's no reference.
+func (sp *svgParser) referenced(st svgState, c *svgparser.Element, prop, typ string) (*svgparser.Element, error) {
+	v := st.style[prop]
+	if v == "" || v == "none" {
+		return nil, nil
+	}
+	id, ok := parseURLRef(v)
+	if !ok {
+		return nil, sp.warn(c, "unsupported %s %q", prop, v)
+	}
+	ref, ok := sp.byID[id]
+	if !ok || ref.Name != typ {
+		return nil, sp.warn(c, "%s references missing %s %q", prop, typ, id)
+	}
+	if sp.using[ref] {
+		return nil, fmt.Errorf("%s %q references itself", typ, id)
+	}
+	return ref, nil
+}
+
+// userBounds returns the bounding box of the shapes in c, in its
+// user coordinates. It returns false if there are no shapes.
+func (sp *svgParser) userBounds(st svgState, c *svgparser.Element) (Bounds, bool, error) {
+	var shapes []svgShape
+	st.xf = Identity()
+	st.clip = nil
+	st.shapes = &shapes
+	if err := sp.parseContent(st, c); err != nil {
+		return Bounds{}, false, err
+	}
+	ps := &Paths{}
+	for _, s := range shapes {
+		ps.P = append(ps.P, s.P...)
+	}
+	ps.TightenBounds()
+	b := ps.Bounds
+	return b, len(ps.P) > 0 && b.Max[0] > b.Min[0] && b.Max[1] > b.Min[1], nil
+}
+
+// unitsXform returns the transform from the units given by the
+// value v of a clipPathUnits (or similar) attribute to the user
+// coordinates of c. It returns false if the units are relative to
+// the bounding box of c, and c has no bounding box.
+func (sp *svgParser) unitsXform(st svgState, c *svgparser.Element, v string) (*Matrix, bool, error) {
+	if v != "objectBoundingBox" {
+		return Identity(), true, nil
+	}
+	b, ok, err := sp.userBounds(st, c)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	return Translate(Vec2{b.Min[0], b.Min[1]}).Compose(Scale(b.Max[0]-b.Min[0], b.Max[1]-b.Min[1])), true, nil
+}
+
+// collectShapes returns the shapes inside a clipPath or mask
+// element, drawn with the given transform.
+func (sp *svgParser) collectShapes(st svgState, ref *svgparser.Element, xf *Matrix) ([]svgShape, error) {
+	var shapes []svgShape
+	cst := svgState{
+		style:    sp.computeStyle(nil, ref),
+		xf:       xf,
+		vp:       st.vp,
+		instance: true,
+		shapes:   &shapes,
+	}
+	sp.using[ref] = true
+	defer delete(sp.using, ref)
+	if err := sp.parseChildren(cst, ref); err != nil {
+		return nil, err
+	}
+	return shapes, nil
+}
+
+// clipRegion returns the region that c, drawn in state st, is
+// clipped to by its clip-path and mask properties, or nil if it
+// isn't clipped. Masks are treated as binary: each shape in the mask
+// either shows or hides what's beneath it, depending on whether its
+// fill is mostly light and opaque.
+func (sp *svgParser) clipRegion(st svgState, c *svgparser.Element) (region, error) {
+	var result region
+	cp, err := sp.referenced(st, c, "clip-path", "clipPath")
+	if err != nil {
+		return nil, err
+	}
+	if cp != nil {
+		r, err := sp.clipPathRegion(st, c, cp)
+		if err != nil {
+			return nil, err
+		}
+		result = r
+	}
+	m, err := sp.referenced(st, c, "mask", "mask")
+	if err != nil {
+		return nil, err
+	}
+	if m != nil {
+		r, err := sp.maskRegion(st, c, m)
+		if err != nil {
+			return nil, err
+		}
+		result = intersect(result, r)
+	}
+	return result, nil
+}
+
+// clipPathRegion returns the region inside the clipPath cp,
+// when it's applied to c.
+func (sp *svgParser) clipPathRegion(st svgState, c, cp *svgparser.Element) (region, error) {
+	xf, ok, err := sp.unitsXform(st, c, cp.Attributes["clipPathUnits"])
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		// Nothing is drawn if there's no bounding box.
+		return unionRegion{}, nil
+	}
+	cpxf, err := parseSVGXForm(cp.Attributes["transform"])
+	if err != nil {
+		return nil, fmt.Errorf("clipPath element: %v", err)
+	}
+	shapes, err := sp.collectShapes(st, cp, st.xf.Compose(cpxf).Compose(xf))
+	if err != nil {
+		return nil, err
+	}
+	var r unionRegion
+	for i := range shapes {
+		r = append(r, shapes[i].area("clip-rule"))
+	}
+	// A clipPath can itself be clipped.
+	sp.using[cp] = true
+	defer delete(sp.using, cp)
+	cpst := st
+	cpst.style = sp.computeStyle(nil, cp)
+	cr, err := sp.clipRegion(cpst, c)
+	if err != nil {
+		return nil, err
+	}
+	return intersect(r, cr), nil
+}
+
+// maskRegion returns the region shown by the mask m, when it's
+// applied to c.
+func (sp *svgParser) maskRegion(st svgState, c, m *svgparser.Element) (region, error) {
+	// The mask only applies inside its rectangle, which by
+	// default extends 10% beyond the bounding box of c.
+	refs := []float64{1, 1, 1, 1}
+	bbxf := st.xf
+	if m.Attributes["maskUnits"] == "userSpaceOnUse" {
+		refs = []float64{st.vp[0], st.vp[1], st.vp[0], st.vp[1]}
+	} else {
+		xf, ok, err := sp.unitsXform(st, c, "objectBoundingBox")
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return unionRegion{}, nil
+		}
+		bbxf = bbxf.Compose(xf)
+	}
+	var rect []float64
+	for i, n := range []string{"x", "y", "width", "height"} {
+		dflt := []float64{-0.1, -0.1, 1.2, 1.2}[i] * refs[i]
+		f, err := parseLengthAttr(m.Attributes, n, refs[i], dflt)
+		if err != nil {
+			return nil, fmt.Errorf("mask: %v", err)
+		}
+		rect = append(rect, f)
+	}
+	if rect[2] <= 0 || rect[3] <= 0 {
+		return unionRegion{}, nil
+	}
+	x0, y0, x1, y1 := rect[0], rect[1], rect[0]+rect[2], rect[1]+rect[3]
+	box := &polygonRegion{rings: [][]Vec2{{
+		bbxf.Apply(Vec2{x0, y0}), bbxf.Apply(Vec2{x1, y0}),
+		bbxf.Apply(Vec2{x1, y1}), bbxf.Apply(Vec2{x0, y1}),
+	}}}
+
+	xf, ok, err := sp.unitsXform(st, c, m.Attributes["maskContentUnits"])
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return unionRegion{}, nil
+	}
+	shapes, err := sp.collectShapes(st, m, st.xf.Compose(xf))
+	if err != nil {
+		return nil, err
+	}
+	sr := &stackRegion{}
+	for i := range shapes {
+		s := &shapes[i]
+		fill, ok := s.style.paintColor("fill", "black")
+		if !ok {
+			continue
+		}
+		sr.layers = append(sr.layers, s.area("fill-rule"))
+		sr.show = append(sr.show, fill.luminance()*opacity(s.style, "fill-opacity")*opacity(s.style, "opacity") >= 0.5)
+	}
+	return intersect(box, sr), nil
+}
+
+// luminance returns the relative luminance of c, from 0 (black)
+// to 1 (white).
+func (c rgb) luminance() float64 {
+	return (0.2125*float64(c.R) + 0.7154*float64(c.G) + 0.0721*float64(c.B)) / 255
+}
+
+// opacity returns the value of an opacity property, which may be
+// a number or a percentage, clamped to the range 0 to 1.
+func opacity(st svgStyle, prop string) float64 {
+	v, ok := st[prop]
+	if !ok {
+		return 1
+	}
+	f, err := parseLength(v, 1)
+	if err != nil {
+		return 1
+	}
+	return math.Max(0, math.Min(1, f))
+}
@@ -0,0 +1,84 @@
+package paths
+
+import "math"
+
+// shading returns the angles of the layers of hatching that shade
+// a fill of the given lightness, from 0 (black) to 1 (white), and
+// the spacing of their lines.
+func (fo *FillOptions) shading(lightness float64) ([]float64, float64) {
+	// The proportion of the paper that a single layer of hatching
+	// covers is the pen width over the spacing.
+	var dark float64
+	if fo.Tone != nil {
+		if s := fo.Tone(lightness); s > 0 {
+			dark = fo.PenWidth / s
+		}
+	} else {
+		dark = 1 - lightness
+	}
+	dark = math.Min(dark, 1)
+	if !(dark > 0) {
+		return nil, 0
+	}
+	// Of the possible layers, whose angles are spread evenly, the
+	// first n are drawn.
+	layers, n := 1, 1
+	if fo.Layers > 1 {
+		layers, n = fo.Layers, int(math.Ceil(dark*float64(fo.Layers)))
+	} else if fo.Cross {
+		layers, n = 2, 2
+	}
+	var angles []float64
+	for i := 0; i < n; i++ {
+		angles = append(angles, fo.Angle+float64(i)*math.Pi/float64(layers))
+	}
+	// Layers that each cover c of the paper together cover
+	// 1-(1-c)^n of it.
+	c := 1 - math.Pow(1-dark, 1/float64(n))
+	return angles, fo.PenWidth / c
+}
+
+// hatchFill returns lines that fill the area enclosed by the paths
+// of an element in state st, which is filled with colour c, using
+// its fill rule, with the pattern for its layer.
+func (sp *svgParser) hatchFill(st svgState, c rgb, ps []Path) []Path {
+	rule := NonZero
+	if st.style["fill-rule"] == "evenodd" {
+		rule = EvenOdd
+	}
+	poly := make([]Path, len(ps))
+	for i := range ps {
+		poly[i] = ps[i].clone()
+		poly[i].Flatten(sp.tol)
+	}
+	fo := sp.opts.Fill
+	pattern, ok := fo.LayerPatterns[st.attrs.Layer]
+	if !ok {
+		pattern = fo.Pattern
+	}
+	var out Paths
+	if pattern != HatchFill {
+		spacing := fo.PenWidth
+		if fo.Shade {
+			single := *fo
+			single.Cross, single.Layers = false, 0
+			op := opacity(st.style, "fill-opacity") * opacity(st.style, "opacity")
+			if _, spacing = single.shading(1 - (1-c.luminance())*op); spacing == 0 {
+				return nil
+			}
+		}
+		out.Fill(poly, rule, pattern, fo.Angle, spacing)
+		return out.P
+	}
+	if !fo.Shade {
+		out.Hatch(poly, fo.Angle, fo.PenWidth, rule, &HatchConfig{Cross: fo.Cross, Link: true})
+		return out.P
+	}
+	// The fill is drawn over white paper.
+	op := opacity(st.style, "fill-opacity") * opacity(st.style, "opacity")
+	angles, spacing := fo.shading(1 - (1-c.luminance())*op)
+	for _, a := range angles {
+		out.Hatch(poly, a, spacing, rule, &HatchConfig{Link: true})
+	}
+	return out.P
+}
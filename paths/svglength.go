@@ -0,0 +1,157 @@
+package paths
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// mmPerPx is the size of an SVG user unit (a CSS pixel) in millimeters.
+// CSS defines a pixel to be 1/96th of an inch.
+const mmPerPx = 25.4 / 96
+
+// defaultFontSize is the font size (in px) used to resolve em and ex
+// units. This is the usual browser default for "medium".
+const defaultFontSize = 16
+
+// svgUnits gives the size in px of each absolute unit understood in
+// SVG lengths.
+var svgUnits = map[string]float64{
+	"":   1,
+	"px": 1,
+	"in": 96,
+	"cm": 96 / 2.54,
+	"mm": 96 / 25.4,
+	"Q":  96 / 101.6,
+	"pt": 96.0 / 72,
+	"pc": 96.0 / 6,
+	"em": defaultFontSize,
+	"ex": defaultFontSize / 2,
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// scanNumber returns the length of the longest prefix of s that is
+// a number in the SVG grammar: an optional sign, digits with an
+// optional fractional part, and an optional exponent.
+// It returns 0 if s doesn't start with a number.
+func scanNumber(s string) int {
+	i := 0
+	if i < len(s) && (s[i] == '+' || s[i] == '-') {
+		i++
+	}
+	digits := 0
+	for i < len(s) && isDigit(s[i]) {
+		i++
+		digits++
+	}
+	if i < len(s) && s[i] == '.' {
+		j := i + 1
+		for j < len(s) && isDigit(s[j]) {
+			j++
+			digits++
+		}
+		if j > i+1 || digits > 0 {
+			i = j
+		}
+	}
+	if digits == 0 {
+		return 0
+	}
+	// An exponent needs at least one digit, so that "1em" is
+	// read as 1 followed by the unit em.
+	if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		j := i + 1
+		if j < len(s) && (s[j] == '+' || s[j] == '-') {
+			j++
+		}
+		if j < len(s) && isDigit(s[j]) {
+			for j < len(s) && isDigit(s[j]) {
+				j++
+			}
+			i = j
+		}
+	}
+	return i
+}
+
+// parseNumber parses s, which must consist of a single number
+// in the SVG grammar.
+func parseNumber(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	n := scanNumber(s)
+	if n == 0 || n != len(s) {
+		return 0, fmt.Errorf("%q is not a number", s)
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+// parseNumberList parses a list of numbers separated by whitespace
+// and/or commas, such as the points of a polygon.
+func parseNumberList(s string) ([]float64, error) {
+	var r []float64
+	i := 0
+	skipSep := func(comma bool) {
+		for i < len(s) && (s[i] == ' ' || s[i] == '\t' || s[i] == '\n' || s[i] == '\r' || (comma && s[i] == ',')) {
+			if s[i] == ',' {
+				comma = false
+			}
+			i++
+		}
+	}
+	skipSep(false)
+	for i < len(s) {
+		n := scanNumber(s[i:])
+		if n == 0 {
+			return nil, fmt.Errorf("expected a number at offset %d of %q", i, s)
+		}
+		f, err := strconv.ParseFloat(s[i:i+n], 64)
+		if err != nil {
+			return nil, err
+		}
+		r = append(r, f)
+		i += n
+		skipSep(true)
+	}
+	return r, nil
+}
+
+// parseLength parses an SVG length (a number followed by an optional
+// unit), and returns its size in px.
+// Percentages are resolved relative to ref.
+func parseLength(s string, ref float64) (float64, error) {
+	s = strings.TrimSpace(s)
+	n := scanNumber(s)
+	if n == 0 {
+		return 0, fmt.Errorf("can't parse %q as a length", s)
+	}
+	f, err := strconv.ParseFloat(s[:n], 64)
+	if err != nil {
+		return 0, err
+	}
+	unit := s[n:]
+	if unit == "%" {
+		return f * ref / 100, nil
+	}
+	scale, ok := svgUnits[unit]
+	if !ok {
+		return 0, fmt.Errorf("%q is not understood by this program as an SVG unit", unit)
+	}
+	return f * scale, nil
+}
+
+// parseLengthAttr parses the named attribute of an element as a length,
+// using dflt if the attribute isn't present.
+func parseLengthAttr(attrs map[string]string, name string, ref, dflt float64) (float64, error) {
+	s, ok := attrs[name]
+	if !ok || strings.TrimSpace(s) == "" {
+		return dflt, nil
+	}
+	f, err := parseLength(s, ref)
+	if err != nil {
+		return 0, fmt.Errorf("bad %s attribute: %v", name, err)
+	}
+	return f, nil
+}
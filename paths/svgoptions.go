@@ -0,0 +1,237 @@
+package paths
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/JoshVarga/svgparser"
+)
+
+// ParseOptions control how SVG files are parsed.
+type ParseOptions struct {
+	// If Strict is set, any part of the file that isn't supported
+	// causes parsing to fail, rather than producing a warning.
+	Strict bool
+
+	// If Tolerance is set, curves are flattened into line segments
+	// that are no further than this distance (in the output unit)
+	// from them. Otherwise, curves are kept as curves, and can be
+	// flattened with Paths.Flatten once they've been resized.
+	Tolerance float64
+
+	// Unit is the unit of the output paths, which may be "mm", "cm",
+	// "in", "pt", "pc", "Q" or "px" (96 to the inch). If it's empty,
+	// the output is in the unit that the width and height of the
+	// document are given in, so that its size is the same as the
+	// numbers in them, or in px if they don't have a unit. If they
+	// have different units, the output is in mm.
+	Unit string
+
+	// If Font is set, text elements are drawn with it.
+	Font *Font
+
+	// If Fill is set, elements that have a fill are hatched, and
+	// filled elements that have no stroke are drawn too.
+	Fill *FillOptions
+}
+
+// FillOptions control how the fills of SVG elements are hatched,
+// or filled with another pattern. The lines are joined up where they
+// can be, to reduce pen lifts.
+type FillOptions struct {
+	// PenWidth is the width of the line the pen draws, in the output
+	// unit. Fills are hatched with lines this far apart, so that
+	// they're solid. If it's zero, fills aren't drawn.
+	PenWidth float64
+
+	// Angle is the angle of the hatching lines (in radians) from the
+	// x axis, turning towards the y axis, which points down the page.
+	Angle float64
+
+	// Pattern is the pattern that fills are drawn with. Fills drawn
+	// with patterns other than HatchFill are shaded with a single
+	// layer, and aren't cross-hatched.
+	Pattern FillPattern
+
+	// LayerPatterns gives the pattern used for fills in each Inkscape
+	// layer, by label, in place of Pattern.
+	LayerPatterns map[string]FillPattern
+
+	// If Cross is set, fills are cross-hatched.
+	Cross bool
+
+	// If Shade is set, fills are shaded rather than solid. The lines
+	// hatching each fill are spaced so that it's about as dark on the
+	// paper as its colour is, taking its opacity into account.
+	Shade bool
+
+	// Tone maps the lightness of a fill, from 0 for black to 1 for
+	// white, to the spacing of its hatching lines when shading. Fills
+	// for which it returns zero are left empty. If it's nil, the
+	// spacing is PenWidth/(1-lightness), so that the lines cover as
+	// much of the paper as the fill is dark.
+	Tone func(lightness float64) float64
+
+	// If Layers is more than 1, darker fills are shaded with up to
+	// this many layers of hatching, at angles spread evenly from
+	// Angle, rather than with closer lines. Otherwise, if Cross is
+	// set, every fill is shaded with two layers at right angles. The
+	// layers are spaced so that together they're as dark as Tone
+	// asks for.
+	Layers int
+}
+
+// A Warning describes part of an SVG file that isn't supported,
+// and that was skipped or approximated when the file was parsed.
+// In strict mode, a Warning is returned as an error.
+type Warning struct {
+	Element string // the name of the element, such as "text"
+	ID      string // the id of the element, if it has one
+	Reason  string // what isn't supported
+}
+
+func (w *Warning) Error() string {
+	if w.ID != "" {
+		return fmt.Sprintf("%s element %q: %s", w.Element, w.ID, w.Reason)
+	}
+	return fmt.Sprintf("%s element: %s", w.Element, w.Reason)
+}
+
+// warn records a warning about the element e, or in strict mode
+// returns it as an error.
+func (sp *svgParser) warn(e *svgparser.Element, format string, args ...interface{}) error {
+	w := Warning{
+		Element: e.Name,
+		ID:      e.Attributes["id"],
+		Reason:  fmt.Sprintf(format, args...),
+	}
+	if sp.opts.Strict {
+		return &w
+	}
+	sp.warnings = append(sp.warnings, w)
+	return nil
+}
+
+// flatten flattens the curves of ps if a tolerance has been set.
+func (sp *svgParser) flatten(ps *Paths) {
+	if sp.opts.Tolerance > 0 {
+		ps.Flatten(sp.opts.Tolerance)
+	}
+}
+
+// outputUnits returns the size of a CSS pixel in the named unit. If
+// the unit is empty, it's the unit that the width and height of the
+// svg element e are given in, or px if they have none. Both
+// directions use the same unit, so if the width and height have
+// different units, it's mm.
+func outputUnits(unit string, e *svgparser.Element) (float64, error) {
+	if unit == "" {
+		unit = "px"
+		found := false
+		for _, attr := range []string{"width", "height"} {
+			s := strings.TrimSpace(e.Attributes[attr])
+			u := s[scanNumber(s):]
+			if _, ok := svgUnits[u]; !ok || s == "" {
+				continue
+			}
+			if u == "" || u == "em" || u == "ex" {
+				u = "px"
+			}
+			if found && u != unit {
+				unit = "mm"
+				break
+			}
+			unit, found = u, true
+		}
+	}
+	px, ok := svgUnits[unit]
+	if !ok || unit == "em" || unit == "ex" {
+		var units []string
+		for u := range svgUnits {
+			if u != "" && u != "em" && u != "ex" {
+				units = append(units, u)
+			}
+		}
+		sort.Strings(units)
+		return 0, fmt.Errorf("unknown output unit %q (want one of %s)", unit, strings.Join(units, ", "))
+	}
+	return 1 / px, nil
+}
+
+// FromSVGWithOptions parses an SVG file like FromSVG, using the given
+// options, which may be nil. It also returns warnings about any parts
+// of the file that aren't supported.
+func FromSVGWithOptions(r io.Reader, opts *ParseOptions) (*Paths, []Warning, error) {
+	sp, err := newSVGParser(r, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	out := &Paths{Bounds: sp.bounds}
+	if err := sp.parse(out); err != nil {
+		return nil, nil, err
+	}
+	sp.flatten(out)
+	return out, sp.warnings, nil
+}
+
+// BoundsFromSVG returns the bounds that FromSVGWithOptions would
+// give the paths of an SVG file, without parsing its elements.
+// It can be used to find how much the paths are going to be
+// resized before they're parsed.
+func BoundsFromSVG(r io.Reader, opts *ParseOptions) (Bounds, error) {
+	sp, err := newSVGParser(r, opts)
+	if err != nil {
+		return Bounds{}, err
+	}
+	return sp.bounds, nil
+}
+
+// FromSVGWithFont parses an SVG file like FromSVG, and also draws
+// text and tspan elements using the given single-stroke font.
+// The text is scaled so that the font's capital letters are
+// 0.7 times the font size.
+func FromSVGWithFont(r io.Reader, f *Font) (*Paths, error) {
+	ps, _, err := FromSVGWithOptions(r, &ParseOptions{Font: f})
+	return ps, err
+}
+
+// ColorLayersFromSVGWithOptions is like ColorLayersFromSVG, but uses
+// the given options, and also returns warnings.
+func ColorLayersFromSVGWithOptions(r io.Reader, opts *ParseOptions) ([]*Layer, []Warning, error) {
+	sp, err := newSVGParser(r, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	sp.byColor = true
+	if err := sp.parse(&Paths{}); err != nil {
+		return nil, nil, err
+	}
+	for _, l := range sp.layers {
+		sp.flatten(l.Paths)
+	}
+	return sp.layers, sp.warnings, nil
+}
+
+// InkscapeLayersFromSVGWithOptions is like InkscapeLayersFromSVG, but
+// uses the given options, and also returns warnings.
+func InkscapeLayersFromSVGWithOptions(r io.Reader, opts *ParseOptions) ([]*Layer, []Warning, error) {
+	sp, err := newSVGParser(r, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	sp.byLayer = true
+	unlayered := &Paths{Bounds: sp.bounds}
+	if err := sp.parse(unlayered); err != nil {
+		return nil, nil, err
+	}
+	sp.flatten(unlayered)
+	for _, l := range sp.layers {
+		sp.flatten(l.Paths)
+	}
+	if len(unlayered.P) > 0 {
+		return append([]*Layer{{Paths: unlayered}}, sp.layers...), sp.warnings, nil
+	}
+	return sp.layers, sp.warnings, nil
+}
@@ -0,0 +1,124 @@
+package paths
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/JoshVarga/svgparser"
+)
+
+// addShape adds a path with the given vertices (in user coordinates)
+// to ps, transformed by xf. If closed is set, the path is a loop
+// whose last vertex repeats the first.
+func addShape(ps *Paths, xf *Matrix, vs []Vec2, closed bool) {
+	if len(vs) < 2 {
+		return
+	}
+	p := Path{V: make([]Vec2, len(vs)), Closed: closed}
+	for i, v := range vs {
+		p.V[i] = xf.Apply(v)
+	}
+	ps.P = append(ps.P, p)
+}
+
+// lengthAttrs parses the named length attributes of e. Each attribute
+// is resolved relative to the corresponding entry of refs, and
+// missing attributes are zero.
+func lengthAttrs(e *svgparser.Element, names []string, refs []float64) ([]float64, error) {
+	r := make([]float64, len(names))
+	for i, n := range names {
+		f, err := parseLengthAttr(e.Attributes, n, refs[i], 0)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", e.Name, err)
+		}
+		r[i] = f
+	}
+	return r, nil
+}
+
+// vpDiag returns the normalized diagonal of the viewport, which is
+// used to resolve percentages that aren't horizontal or vertical.
+func vpDiag(vp Vec2) float64 {
+	return math.Sqrt((vp[0]*vp[0] + vp[1]*vp[1]) / 2)
+}
+
+// parseRect adds the outline of a rect element, which may have
+// rounded corners, as a closed path.
+func parseRect(ps *Paths, xf *Matrix, vp Vec2, e *svgparser.Element) error {
+	a, err := lengthAttrs(e, []string{"x", "y", "width", "height"}, []float64{vp[0], vp[1], vp[0], vp[1]})
+	if err != nil {
+		return err
+	}
+	x, y, w, h := a[0], a[1], a[2], a[3]
+	if w <= 0 || h <= 0 {
+		// A rect with no area isn't rendered.
+		return nil
+	}
+	rx, err := parseLengthAttr(e.Attributes, "rx", vp[0], -1)
+	if err != nil {
+		return err
+	}
+	ry, err := parseLengthAttr(e.Attributes, "ry", vp[1], -1)
+	if err != nil {
+		return err
+	}
+	// If only one of rx and ry is given, the other is the same.
+	if rx < 0 {
+		rx = ry
+	}
+	if ry < 0 {
+		ry = rx
+	}
+	p := RoundedRect(Bounds{Min: Vec2{x, y}, Max: Vec2{x + w, y + h}}, rx, ry)
+	p.transform(xf.Apply)
+	ps.P = append(ps.P, p)
+	return nil
+}
+
+// parseEllipse adds the outline of a circle or ellipse element as
+// a closed path, starting and ending at the rightmost point.
+func parseEllipse(ps *Paths, xf *Matrix, vp Vec2, e *svgparser.Element) error {
+	var a []float64
+	var err error
+	if e.Name == "circle" {
+		a, err = lengthAttrs(e, []string{"cx", "cy", "r"}, []float64{vp[0], vp[1], vpDiag(vp)})
+		if err == nil {
+			a = append(a, a[2])
+		}
+	} else {
+		a, err = lengthAttrs(e, []string{"cx", "cy", "rx", "ry"}, []float64{vp[0], vp[1], vp[0], vp[1]})
+	}
+	if err != nil {
+		return err
+	}
+	cx, cy, rx, ry := a[0], a[1], a[2], a[3]
+	if rx <= 0 || ry <= 0 {
+		return nil
+	}
+	p := Ellipse(Vec2{cx, cy}, rx, ry, 0)
+	p.transform(xf.Apply)
+	ps.P = append(ps.P, p)
+	return nil
+}
+
+// parsePolyline adds the path given by the points of a polyline
+// or polygon element. Polygons are closed.
+func parsePolyline(ps *Paths, xf *Matrix, e *svgparser.Element) error {
+	fs, err := parseNumberList(e.Attributes["points"])
+	if err != nil {
+		return fmt.Errorf("%s: bad points: %v", e.Name, err)
+	}
+	if len(fs)%2 != 0 {
+		return fmt.Errorf("%s: odd number of coordinates in points %q", e.Name, e.Attributes["points"])
+	}
+	var vs []Vec2
+	for i := 0; i < len(fs); i += 2 {
+		vs = append(vs, Vec2{fs[i], fs[i+1]})
+	}
+	closed := e.Name == "polygon"
+	if closed && len(vs) > 0 {
+		vs = append(vs, vs[0])
+	}
+	addShape(ps, xf, vs, closed)
+	return nil
+}
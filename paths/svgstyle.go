@@ -0,0 +1,311 @@
+package paths
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/JoshVarga/svgparser"
+)
+
+// svgStyleProps are the style properties that are used when importing
+// svg files, and whether each one is inherited by child elements.
+// Each can be given as a presentation attribute, in an inline style,
+// or in a stylesheet.
+var svgStyleProps = map[string]bool{
+	"clip-path":    false,
+	"clip-rule":    true,
+	"color":        true,
+	"display":      false,
+	"fill":         true,
+	"fill-opacity": true,
+	"fill-rule":    true,
+	"font-size":    true,
+	"mask":         false,
+	"opacity":      false,
+	"stroke":       true,
+	"stroke-width": true,
+	"text-anchor":  true,
+	"visibility":   true,
+}
+
+// svgStyle holds the values of the style properties of an element.
+type svgStyle map[string]string
+
+// cssSelector is a simple css selector, such as "path", ".c1",
+// "#id" or "g.c1.c2". Empty fields match anything.
+type cssSelector struct {
+	tag     string
+	id      string
+	classes []string
+}
+
+// cssRule is a rule from a stylesheet with a single simple selector.
+type cssRule struct {
+	sel   cssSelector
+	spec  int // specificity of the selector
+	order int // position of the rule in the stylesheets
+	decls [][2]string
+}
+
+// parseDeclarations parses a list of css declarations, as found
+// in a style attribute or a rule in a stylesheet.
+func parseDeclarations(s string) [][2]string {
+	var r [][2]string
+	for _, d := range strings.Split(s, ";") {
+		i := strings.Index(d, ":")
+		if i < 0 {
+			continue
+		}
+		k := strings.ToLower(strings.TrimSpace(d[:i]))
+		v := strings.TrimSpace(d[i+1:])
+		v = strings.TrimSpace(strings.TrimSuffix(v, "!important"))
+		if k != "" {
+			r = append(r, [2]string{k, v})
+		}
+	}
+	return r
+}
+
+// parseSelector parses a simple css selector. It returns false for
+// selectors that aren't understood, including those with combinators,
+// attribute selectors or pseudo-classes.
+func parseSelector(s string) (cssSelector, int, bool) {
+	var sel cssSelector
+	if s == "" || strings.ContainsAny(s, " \t\n>+~[:") {
+		return sel, 0, false
+	}
+	spec := 0
+	i := 0
+	name := func() string {
+		j := i
+		for j < len(s) && s[j] != '.' && s[j] != '#' {
+			j++
+		}
+		n := s[i:j]
+		i = j
+		return n
+	}
+	if s[0] == '*' {
+		i++
+	} else if s[0] != '.' && s[0] != '#' {
+		sel.tag = name()
+		spec++
+	}
+	for i < len(s) {
+		c := s[i]
+		i++
+		n := name()
+		if n == "" {
+			return sel, 0, false
+		}
+		if c == '#' {
+			sel.id = n
+			spec += 10000
+		} else if c == '.' {
+			sel.classes = append(sel.classes, n)
+			spec += 100
+		} else {
+			return sel, 0, false
+		}
+	}
+	return sel, spec, true
+}
+
+// parseStylesheet parses the contents of a <style> element, adding
+// rules to the given list. Rules with selectors that aren't understood,
+// and at-rules such as @media, are ignored.
+func parseStylesheet(rules []cssRule, css string) []cssRule {
+	// Remove comments.
+	for {
+		i := strings.Index(css, "/*")
+		if i < 0 {
+			break
+		}
+		j := strings.Index(css[i+2:], "*/")
+		if j < 0 {
+			css = css[:i]
+			break
+		}
+		css = css[:i] + " " + css[i+2+j+2:]
+	}
+	for {
+		css = strings.TrimSpace(css)
+		if css == "" {
+			return rules
+		}
+		open := strings.Index(css, "{")
+		if strings.HasPrefix(css, "@") {
+			// Skip an at-rule: either a statement ending in ;
+			// or a block.
+			semi := strings.Index(css, ";")
+			if semi >= 0 && (open < 0 || semi < open) {
+				css = css[semi+1:]
+				continue
+			}
+			if open < 0 {
+				return rules
+			}
+			depth := 0
+			end := len(css)
+			for i := open; i < len(css); i++ {
+				if css[i] == '{' {
+					depth++
+				} else if css[i] == '}' {
+					depth--
+					if depth == 0 {
+						end = i + 1
+						break
+					}
+				}
+			}
+			css = css[end:]
+			continue
+		}
+		if open < 0 {
+			return rules
+		}
+		close := strings.Index(css[open:], "}")
+		if close < 0 {
+			close = len(css) - open
+		}
+		decls := parseDeclarations(css[open+1 : open+close])
+		for _, s := range strings.Split(css[:open], ",") {
+			sel, spec, ok := parseSelector(strings.TrimSpace(s))
+			if !ok {
+				continue
+			}
+			rules = append(rules, cssRule{sel: sel, spec: spec, order: len(rules), decls: decls})
+		}
+		if open+close+1 >= len(css) {
+			return rules
+		}
+		css = css[open+close+1:]
+	}
+}
+
+func (sel *cssSelector) matches(e *svgparser.Element) bool {
+	if sel.tag != "" && sel.tag != e.Name {
+		return false
+	}
+	if sel.id != "" && sel.id != e.Attributes["id"] {
+		return false
+	}
+	classes := strings.Fields(e.Attributes["class"])
+	for _, c := range sel.classes {
+		found := false
+		for _, ec := range classes {
+			found = found || c == ec
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// computeStyle returns the style of e, given the style of its parent.
+// Inherited properties are copied from the parent, and then
+// presentation attributes, stylesheet rules (in order of specificity)
+// and the inline style attribute are applied in turn.
+func (sp *svgParser) computeStyle(parent svgStyle, e *svgparser.Element) svgStyle {
+	st := svgStyle{}
+	for k, v := range parent {
+		if svgStyleProps[k] {
+			st[k] = v
+		}
+	}
+	set := func(k, v string) {
+		if _, ok := svgStyleProps[k]; !ok {
+			return
+		}
+		if v == "inherit" {
+			if pv, ok := parent[k]; ok {
+				st[k] = pv
+			} else {
+				delete(st, k)
+			}
+			return
+		}
+		st[k] = v
+	}
+	for k, v := range e.Attributes {
+		set(k, strings.TrimSpace(v))
+	}
+	var matched []*cssRule
+	for i := range sp.css {
+		if sp.css[i].sel.matches(e) {
+			matched = append(matched, &sp.css[i])
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		if matched[i].spec != matched[j].spec {
+			return matched[i].spec < matched[j].spec
+		}
+		return matched[i].order < matched[j].order
+	})
+	for _, r := range matched {
+		for _, d := range r.decls {
+			set(d[0], d[1])
+		}
+	}
+	for _, d := range parseDeclarations(e.Attributes["style"]) {
+		set(d[0], d[1])
+	}
+	// Opacity isn't inherited, but the opacity of a group applies
+	// to everything in it, so an element's opacity is multiplied by
+	// its parent's.
+	if _, ok := parent["opacity"]; ok {
+		st["opacity"] = strconv.FormatFloat(opacity(st, "opacity")*opacity(parent, "opacity"), 'g', -1, 64)
+	}
+	return st
+}
+
+// paintColor returns the colour of a paint property (stroke or fill),
+// or false if it isn't painted. A paint server such as a gradient
+// is replaced by its fallback colour if it has one, and black
+// otherwise. If the property isn't set, dflt is used.
+func (st svgStyle) paintColor(prop string, dflt string) (rgb, bool) {
+	v, ok := st[prop]
+	if !ok {
+		v = dflt
+	}
+	if strings.HasPrefix(v, "url(") {
+		i := strings.Index(v, ")")
+		v = strings.TrimSpace(v[i+1:])
+		if v == "" {
+			return rgb{}, true
+		}
+	}
+	current, ok, err := parseColor(st["color"], rgb{})
+	if err != nil || !ok {
+		current = rgb{}
+	}
+	c, ok, err := parseColor(v, current)
+	if err != nil {
+		return rgb{}, true
+	}
+	return c, ok
+}
+
+// unknownColor returns the value of the first of the stroke, fill
+// and color properties that's set to something that isn't a colour,
+// and which paintColor treats as black.
+func (st svgStyle) unknownColor() (string, bool) {
+	for _, prop := range []string{"stroke", "fill", "color"} {
+		v, ok := st[prop]
+		if !ok {
+			continue
+		}
+		if strings.HasPrefix(v, "url(") {
+			v = strings.TrimSpace(v[strings.Index(v, ")")+1:])
+			if v == "" {
+				continue
+			}
+		}
+		if _, _, err := parseColor(v, rgb{}); err != nil {
+			return st[prop], true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,149 @@
+package paths
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParseColor(t *testing.T) {
+	cases := []struct {
+		s    string
+		want string
+		ok   bool
+	}{
+		{"red", "#ff0000", true},
+		{"Red", "#ff0000", true},
+		{"cornflowerblue", "#6495ed", true},
+		{"#f00", "#ff0000", true},
+		{"#F00C", "#ff0000", true},
+		{"#12aB34", "#12ab34", true},
+		{"#12ab34ff", "#12ab34", true},
+		{"rgb(1, 2, 3)", "#010203", true},
+		{"rgb(100%,0%,50%)", "#ff0080", true},
+		{"rgba(1,2,3,0.5)", "#010203", true},
+		{"rgb(300 -2 3)", "#ff0003", true},
+		{"currentColor", "#0000ff", true},
+		{"none", "", false},
+		{"transparent", "", false},
+	}
+	for _, tc := range cases {
+		got, ok, err := parseColor(tc.s, rgb{0, 0, 255})
+		if err != nil {
+			t.Errorf("parseColor(%q) failed: %v", tc.s, err)
+			continue
+		}
+		if ok != tc.ok || (ok && got.String() != tc.want) {
+			t.Errorf("parseColor(%q) = %v, %v, want %s, %v", tc.s, got, ok, tc.want, tc.ok)
+		}
+	}
+	for _, bad := range []string{"", "#12", "#ggg", "rgb(1,2)", "rgb(1,2,3", "reddish"} {
+		if _, _, err := parseColor(bad, rgb{}); err == nil {
+			t.Errorf("parseColor(%q) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestParseSelector(t *testing.T) {
+	cases := []struct {
+		s    string
+		ok   bool
+		spec int
+	}{
+		{"path", true, 1},
+		{".a", true, 100},
+		{"#x", true, 10000},
+		{"g.a.b", true, 201},
+		{"*", true, 0},
+		{"g path", false, 0},
+		{"g>path", false, 0},
+		{"a:hover", false, 0},
+		{"[fill]", false, 0},
+		{"g.", false, 0},
+	}
+	for _, tc := range cases {
+		_, spec, ok := parseSelector(tc.s)
+		if ok != tc.ok || spec != tc.spec {
+			t.Errorf("parseSelector(%q) = spec %d, %v, want spec %d, %v", tc.s, spec, ok, tc.spec, tc.ok)
+		}
+	}
+}
+
+func TestColorLayersFromSVG(t *testing.T) {
+	svg := `<svg width="100mm" height="100mm" viewBox="0 0 100 100">
+		<style>
+			/* a comment { with braces } */
+			@import url(other.css);
+			@media print { path { stroke: pink } }
+			.pen2, #special { stroke: #00f }
+			path.pen3 { stroke: lime !important }
+			g path { stroke: pink }
+		</style>
+		<path d="M 0 0 1 0"/>
+		<path stroke="red" d="M 0 1 1 1"/>
+		<g stroke="#f00">
+			<path d="M 0 2 1 2"/>
+			<path style="stroke: rgb(0, 0, 255)" d="M 0 3 1 3"/>
+			<path class="pen3" stroke="black" d="M 0 4 1 4"/>
+		</g>
+		<path class="x pen2" stroke="red" d="M 0 5 1 5"/>
+		<path class="pen2" style="stroke:red" d="M 0 6 1 6"/>
+		<circle id="special" cx="50" cy="50" r="1"/>
+		<g style="color: red"><path stroke="currentColor" d="M 0 7 1 7"/></g>
+		<path stroke="none" d="M 0 8 1 8"/>
+	</svg>`
+	layers, err := ColorLayersFromSVG(strings.NewReader(svg))
+	if err != nil {
+		t.Fatalf("failed to parse svg: %v", err)
+	}
+	var got []string
+	for _, l := range layers {
+		var ys []string
+		for _, p := range l.Paths.P {
+			if len(p.V) == 2 {
+				ys = append(ys, fmt.Sprint(p.V[0][1]))
+			} else {
+				ys = append(ys, "circle")
+			}
+		}
+		got = append(got, l.Name+":"+strings.Join(ys, ","))
+	}
+	want := []string{
+		"#000000:0",
+		"#ff0000:1,2,6,7",
+		"#0000ff:3,5,circle",
+		"#00ff00:4",
+	}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("got layers %v, want %v", got, want)
+	}
+}
+
+func TestSVGUnknownColor(t *testing.T) {
+	svg := `<svg width="100" height="100">
+		<defs><path id="d" stroke="nocolour" d="M 0 0 1 0"/></defs>
+		<path id="a" stroke="blurple" d="M 0 1 1 1"/>
+		<path id="b" stroke="blurple" d="M 0 2 1 2"/>
+		<path id="c" stroke="url(#g) bad" d="M 0 3 1 3"/>
+		<path id="e" stroke="url(#g)" d="M 0 4 1 4"/>
+	</svg>`
+	layers, warnings, err := ColorLayersFromSVGWithOptions(strings.NewReader(svg), nil)
+	if err != nil {
+		t.Fatalf("failed to parse svg: %v", err)
+	}
+	var got []string
+	for _, w := range warnings {
+		got = append(got, w.Element+"/"+w.ID)
+	}
+	if want := []string{"path/a", "path/c"}; strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("got warnings %v, want %v", got, want)
+	}
+	if len(layers) != 1 || layers[0].Name != "#000000" || len(layers[0].Paths.P) != 4 {
+		t.Errorf("got layers %v, want all the paths drawn in black", layers)
+	}
+
+	_, _, err = FromSVGWithOptions(strings.NewReader(svg), &ParseOptions{Strict: true})
+	if w, ok := err.(*Warning); !ok || w.ID != "a" {
+		t.Errorf("strict mode: got error %v, want a warning about path a", err)
+	}
+}
@@ -0,0 +1,221 @@
+package paths
+
+import (
+	"math"
+	"strings"
+
+	"github.com/JoshVarga/svgparser"
+)
+
+// capHeight is the height of capital letters as a fraction of the
+// font size. It's used to scale a Font to a given font size.
+const capHeight = 0.7
+
+// emHeight returns the font size of f in the font's own units,
+// estimated from the height of its capital letters.
+func (f *Font) emHeight() float64 {
+	for _, r := range "HIMX" {
+		if g, ok := f.Glyph[r]; ok && g.Height > 0 {
+			return g.Height / capHeight
+		}
+	}
+	if f.LineAdvance > 0 {
+		return f.LineAdvance
+	}
+	return 1
+}
+
+// textRun is a piece of text from a text or tspan element, all
+// drawn with the same font size.
+type textRun struct {
+	text     string
+	fontSize float64
+	x, y     []float64 // absolute position, if set
+	dx, dy   []float64 // relative position, if set
+	xf       *Matrix
+}
+
+// collapseSpace replaces each run of whitespace in s by a single space,
+// as svg does for text unless xml:space is preserve.
+func collapseSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// parseFontSize returns the font size (in user units) given by
+// the style of an element.
+func parseFontSize(st svgStyle) float64 {
+	v, ok := st["font-size"]
+	if !ok {
+		return defaultFontSize
+	}
+	switch v {
+	case "xx-small":
+		return defaultFontSize * 3 / 5
+	case "x-small":
+		return defaultFontSize * 3 / 4
+	case "small":
+		return defaultFontSize * 8 / 9
+	case "medium":
+		return defaultFontSize
+	case "large":
+		return defaultFontSize * 6 / 5
+	case "x-large":
+		return defaultFontSize * 3 / 2
+	case "xx-large":
+		return defaultFontSize * 2
+	}
+	f, err := parseLength(v, defaultFontSize)
+	if err != nil || f <= 0 {
+		return defaultFontSize
+	}
+	return f
+}
+
+// textPositions parses an attribute of a text or tspan element that
+// contains a list of coordinates, such as x or dy.
+func textPositions(e *svgparser.Element, name string, ref float64) []float64 {
+	var r []float64
+	for _, f := range strings.FieldsFunc(e.Attributes[name], func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\n' || r == '\r'
+	}) {
+		v, err := parseLength(f, ref)
+		if err != nil {
+			return nil
+		}
+		r = append(r, v)
+	}
+	return r
+}
+
+// textRuns returns the runs of text in a text element, in document
+// order: each piece of text directly in the element, and the text in
+// each tspan between them.
+func (sp *svgParser) textRuns(st svgState, e *svgparser.Element, runs []textRun) []textRun {
+	text := []string{e.Content}
+	if src := sp.source[e]; src != nil {
+		text = src.text
+	}
+	fontSize := parseFontSize(st.style)
+	// The element's position applies to the start of its first piece
+	// of text, even if that's empty.
+	runs = append(runs, textRun{
+		text:     text[0],
+		fontSize: fontSize,
+		x:        textPositions(e, "x", st.vp[0]),
+		y:        textPositions(e, "y", st.vp[1]),
+		dx:       textPositions(e, "dx", st.vp[0]),
+		dy:       textPositions(e, "dy", st.vp[1]),
+		xf:       st.xf,
+	})
+	for i, c := range e.Children {
+		if c.Name == "tspan" {
+			cst := st
+			cst.style = sp.computeStyle(st.style, c)
+			if cst.style["display"] != "none" {
+				if xf, err := parseSVGXForm(c.Attributes["transform"]); err == nil {
+					cst.xf = cst.xf.Compose(xf)
+				}
+				runs = sp.textRuns(cst, c, runs)
+			}
+		}
+		if i+1 < len(text) {
+			runs = append(runs, textRun{text: text[i+1], fontSize: fontSize, xf: st.xf})
+		}
+	}
+	return runs
+}
+
+// parseText draws a text element using the parser's font.
+// Glyphs that aren't in the font are skipped.
+func (sp *svgParser) parseText(out *Paths, st svgState, e *svgparser.Element) {
+	runs := sp.textRuns(st, e, nil)
+	anchor := st.style["text-anchor"]
+
+	var pos Vec2
+	// The text is drawn in chunks, each starting at an absolute
+	// x position, that are aligned according to the text-anchor.
+	type glyphPath struct {
+		p  Path
+		xf *Matrix
+	}
+	var chunk []glyphPath
+	var chunkStart float64
+	flush := func() {
+		shift := 0.0
+		if anchor == "middle" {
+			shift = (pos[0] - chunkStart) / 2
+		} else if anchor == "end" {
+			shift = pos[0] - chunkStart
+		}
+		for _, gp := range chunk {
+			xf := gp.xf
+			gp.p.transform(func(v Vec2) Vec2 { return xf.Apply(Vec2{v[0] - shift, v[1]}) })
+			out.P = append(out.P, gp.p)
+		}
+		chunk = nil
+	}
+	// space is how far to move along for whitespace before the next
+	// text, which is collapsed to a single space, in the font size of
+	// the run it's in.
+	space, drawn := 0.0, false
+	for _, run := range runs {
+		if len(run.x) > 0 {
+			flush()
+			pos[0] = run.x[0]
+			chunkStart = pos[0]
+			space = 0
+		}
+		if len(run.y) > 0 {
+			pos[1] = run.y[0]
+		}
+		if len(run.dx) > 0 {
+			pos[0] += run.dx[0]
+		}
+		if len(run.dy) > 0 {
+			pos[1] += run.dy[0]
+		}
+		scale := run.fontSize / sp.opts.Font.emHeight()
+		runSpace := 0.0
+		if g, ok := sp.opts.Font.Glyph[' ']; ok {
+			runSpace = g.Advance * scale
+		}
+		if space == 0 && drawn && hasSpace(run.text, true) {
+			space = runSpace
+		}
+		text := collapseSpace(run.text)
+		if text == "" {
+			continue
+		}
+		pos[0] += space
+		space = 0
+		var rs []rune
+		for _, r := range text {
+			if _, ok := sp.opts.Font.Glyph[r]; ok {
+				rs = append(rs, r)
+			}
+		}
+		pgs, err := LayoutText(sp.opts.Font, string(rs), scale, math.Inf(1))
+		if err != nil || len(pgs) == 0 {
+			continue
+		}
+		for _, p := range GlyphsToPaths(pos, pgs).P {
+			chunk = append(chunk, glyphPath{p, run.xf})
+		}
+		last := pgs[len(pgs)-1]
+		pos[0] += last.Pos[0] + last.G.Advance*scale
+		drawn = true
+		if hasSpace(run.text, false) {
+			space = runSpace
+		}
+	}
+	flush()
+}
+
+// hasSpace reports whether s starts (or ends, if atStart
+// is false) with whitespace.
+func hasSpace(s string, atStart bool) bool {
+	if atStart {
+		return len(strings.TrimLeft(s, " \t\r\n")) < len(s)
+	}
+	return len(strings.TrimRight(s, " \t\r\n")) < len(s)
+}
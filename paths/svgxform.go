@@ -0,0 +1,142 @@
+package paths
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// svgXformArgs gives the allowed number of arguments for each
+// transform function.
+var svgXformArgs = map[string][]int{
+	"matrix":    {6},
+	"translate": {1, 2},
+	"scale":     {1, 2},
+	"rotate":    {1, 3},
+	"skewX":     {1},
+	"skewY":     {1},
+}
+
+func parseSingleXform(name string, fa []float64) (*Matrix, error) {
+	ok := false
+	for _, n := range svgXformArgs[name] {
+		ok = ok || n == len(fa)
+	}
+	if !ok {
+		return nil, fmt.Errorf("%s transform should have %v parameters: got %v", name, svgXformArgs[name], fa)
+	}
+	deg := math.Pi / 180
+	switch name {
+	case "translate":
+		if len(fa) == 1 {
+			fa = append(fa, 0)
+		}
+		return Translate(Vec2{fa[0], fa[1]}), nil
+	case "matrix":
+		return &Matrix{
+			M: [3][3]float64{
+				{fa[0], fa[2], fa[4]},
+				{fa[1], fa[3], fa[5]},
+				{0, 0, 1},
+			},
+		}, nil
+	case "scale":
+		if len(fa) == 1 {
+			fa = append(fa, fa[0])
+		}
+		return Scale(fa[0], fa[1]), nil
+	case "rotate":
+		c, s := math.Cos(fa[0]*deg), math.Sin(fa[0]*deg)
+		rot := &Matrix{
+			M: [3][3]float64{
+				{c, -s, 0},
+				{s, c, 0},
+				{0, 0, 1},
+			},
+		}
+		if len(fa) == 1 {
+			return rot, nil
+		}
+		return Translate(Vec2{fa[1], fa[2]}).Compose(rot).Compose(Translate(Vec2{-fa[1], -fa[2]})), nil
+	case "skewX":
+		return Skew(fa[0]*deg, 0), nil
+	case "skewY":
+		return Skew(0, fa[0]*deg), nil
+	}
+	return nil, fmt.Errorf("unknown transform function %q", name)
+}
+
+func isXformSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// parseSVGXForm parses an svg transform list, such as
+// "translate(10, 20) rotate(45)", and returns the combined transform.
+// Transforms may be separated by whitespace and/or a comma, and
+// so may arguments. Numbers may be packed together when
+// unambiguous, for example "translate(10-5)".
+func parseSVGXForm(x string) (*Matrix, error) {
+	xf := Identity()
+	i := 0
+	skipSpace := func() {
+		for i < len(x) && isXformSpace(x[i]) {
+			i++
+		}
+	}
+	// skipSep skips whitespace, and at most one comma.
+	skipSep := func() {
+		skipSpace()
+		if i < len(x) && x[i] == ',' {
+			i++
+			skipSpace()
+		}
+	}
+	fail := func(f string, args ...interface{}) (*Matrix, error) {
+		return nil, fmt.Errorf("failed to parse transform %q at offset %d: %s", x, i, fmt.Sprintf(f, args...))
+	}
+	skipSpace()
+	for i < len(x) {
+		start := i
+		for i < len(x) && (x[i] >= 'a' && x[i] <= 'z' || x[i] >= 'A' && x[i] <= 'Z') {
+			i++
+		}
+		name := x[start:i]
+		if _, ok := svgXformArgs[name]; !ok {
+			return fail("expected transform name, but got %q", name)
+		}
+		skipSpace()
+		if i >= len(x) || x[i] != '(' {
+			return fail("expected ( after %s", name)
+		}
+		i++
+		skipSpace()
+		var args []float64
+		for i < len(x) && x[i] != ')' {
+			if len(args) > 0 {
+				skipSep()
+			}
+			n := scanNumber(x[i:])
+			if n == 0 {
+				return fail("expected number or )")
+			}
+			f, err := strconv.ParseFloat(x[i:i+n], 64)
+			if err != nil {
+				return fail("%v", err)
+			}
+			args = append(args, f)
+			i += n
+			skipSpace()
+		}
+		if i >= len(x) {
+			return fail("missing )")
+		}
+		i++
+		nxf, err := parseSingleXform(name, args)
+		if err != nil {
+			return nil, err
+		}
+		xf = xf.Compose(nxf)
+		skipSep()
+	}
+	return xf, nil
+}
@@ -0,0 +1,104 @@
+package paths
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestParseSVGXForm(t *testing.T) {
+	s2 := math.Sqrt2 / 2
+	t30 := math.Tan(math.Pi / 6)
+	cases := []struct {
+		x    string
+		want [6]float64 // a, b, c, d, e, f as in the svg matrix transform
+	}{
+		{"", [6]float64{1, 0, 0, 1, 0, 0}},
+		{"  ", [6]float64{1, 0, 0, 1, 0, 0}},
+		{"translate(10)", [6]float64{1, 0, 0, 1, 10, 0}},
+		{"translate(10-5)", [6]float64{1, 0, 0, 1, 10, -5}},
+		{"translate(10,-5)", [6]float64{1, 0, 0, 1, 10, -5}},
+		{"translate( 10 , 20 )", [6]float64{1, 0, 0, 1, 10, 20}},
+		{"translate (10 20)", [6]float64{1, 0, 0, 1, 10, 20}},
+		{"translate(.5.5)", [6]float64{1, 0, 0, 1, 0.5, 0.5}},
+		{"translate(1e-3,2E+1)", [6]float64{1, 0, 0, 1, 0.001, 20}},
+		{"translate(+1e1)", [6]float64{1, 0, 0, 1, 10, 0}},
+		{"scale(2)", [6]float64{2, 0, 0, 2, 0, 0}},
+		{"scale(2,-1)", [6]float64{2, 0, 0, -1, 0, 0}},
+		{"matrix(1 2 3 4 5 6)", [6]float64{1, 2, 3, 4, 5, 6}},
+		{"matrix(1,2,3,4,5,6)", [6]float64{1, 2, 3, 4, 5, 6}},
+		{"rotate(90)", [6]float64{0, 1, -1, 0, 0, 0}},
+		{"rotate(45)", [6]float64{s2, s2, -s2, s2, 0, 0}},
+		{"rotate(90 10 10)", [6]float64{0, 1, -1, 0, 20, 0}},
+		{"skewX(30)", [6]float64{1, 0, t30, 1, 0, 0}},
+		{"skewY(30)", [6]float64{1, t30, 0, 1, 0, 0}},
+		{"translate(10,20) scale(2)", [6]float64{2, 0, 0, 2, 10, 20}},
+		{"translate(10,20),scale(2)", [6]float64{2, 0, 0, 2, 10, 20}},
+		{"translate(10,20)scale(2)", [6]float64{2, 0, 0, 2, 10, 20}},
+		{"scale(2) translate(10,20)", [6]float64{2, 0, 0, 2, 20, 40}},
+		{"\n\ttranslate(1 , 2)\n\tscale( 3 )\n", [6]float64{3, 0, 0, 3, 1, 2}},
+		// From an Inkscape file.
+		{"matrix(0.26458333,0,0,0.26458333,-12.5,3.1e-6)", [6]float64{0.26458333, 0, 0, 0.26458333, -12.5, 3.1e-6}},
+		// From an Illustrator file.
+		{"matrix(1 0 0 -1 0 841.89)", [6]float64{1, 0, 0, -1, 0, 841.89}},
+	}
+	for _, tc := range cases {
+		got, err := parseSVGXForm(tc.x)
+		if err != nil {
+			t.Errorf("parseSVGXForm(%q) failed: %v", tc.x, err)
+			continue
+		}
+		m := got.M
+		gm := [6]float64{m[0][0], m[1][0], m[0][1], m[1][1], m[0][2], m[1][2]}
+		for i := range gm {
+			if math.Abs(gm[i]-tc.want[i]) > 1e-9 {
+				t.Errorf("parseSVGXForm(%q) = %v, want %v", tc.x, gm, tc.want)
+				break
+			}
+		}
+	}
+}
+
+func TestParseSVGXFormErrors(t *testing.T) {
+	for _, x := range []string{
+		"translate",
+		"translate(",
+		"translate(1",
+		"translate(1,)",
+		"translate(,1)",
+		"translate(1,,2)",
+		"translate(1 2 3)",
+		"rotate(1 2)",
+		"matrix(1 2 3 4 5)",
+		"shear(1)",
+		"translate(1),,scale(2)",
+		"translate(1px)",
+	} {
+		if _, err := parseSVGXForm(x); err == nil {
+			t.Errorf("parseSVGXForm(%q) succeeded, want error", x)
+		}
+	}
+}
+
+func TestSVGElementTransforms(t *testing.T) {
+	svg := `<svg width="100mm" height="100mm" viewBox="0 0 100 100">
+		<path transform="translate(10 20)" d="M 0 0 10 0"/>
+		<line transform="scale(2)" x1="1" y1="1" x2="2" y2="1"/>
+		<rect transform="rotate(90)" width="10" height="10"/>
+	</svg>`
+	got, err := FromSVG(strings.NewReader(svg))
+	if err != nil {
+		t.Fatalf("failed to parse svg: %v", err)
+	}
+	want := &Paths{
+		Bounds: got.Bounds,
+		P: []Path{
+			{V: []Vec2{{10, 20}, {20, 20}}},
+			{V: []Vec2{{2, 2}, {4, 2}}},
+			{V: []Vec2{{0, 0}, {0, 10}, {-10, 10}, {-10, 0}, {0, 0}}, Closed: true},
+		},
+	}
+	if !pathsNear(got, want) {
+		t.Errorf("got %v, want %v", got.P, want.P)
+	}
+}